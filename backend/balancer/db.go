@@ -2,6 +2,8 @@ package balancer
 
 import (
 	"context"
+	"io"
+	"strconv"
 
 	"github.com/upfluence/sql"
 )
@@ -14,19 +16,80 @@ func NewDB(bb BalancerBuilder, dbs ...sql.DB) sql.DB {
 		return dbs[0]
 	}
 
-	return &db{b: bb.Build(dbs), driver: dbs[0].Driver()}
+	nodes := make(map[sql.DB]string, len(dbs))
+
+	for i, d := range dbs {
+		nodes[d] = strconv.Itoa(i)
+	}
+
+	return &db{
+		b:      bb.Build(dbs),
+		driver: dbs[0].Driver(),
+		dbs:    dbs,
+		nodes:  nodes,
+	}
 }
 
 type db struct {
 	b Balancer
 
 	driver string
+	dbs    []sql.DB
+	nodes  map[sql.DB]string
+}
+
+func (d *db) get(ctx context.Context) (sql.DB, CloseFunc, error) {
+	db, cfn, err := d.b.Get(ctx)
+
+	if err == nil {
+		sql.RecordRoute(ctx, d.nodes[db])
+	}
+
+	return db, cfn, err
 }
 
 func (d *db) Driver() string { return d.driver }
 
+// Close releases the resources held by the balancer, such as the goroutines
+// probing the ejected DBs.
+func (d *db) Close() error {
+	if c, ok := d.b.(io.Closer); ok {
+		return c.Close()
+	}
+
+	return nil
+}
+
+// Stats reports the statistics of every DB, ejected ones included, labeled
+// with their index.
+func (d *db) Stats() []sql.NodeStats {
+	var res []sql.NodeStats
+
+	for _, db := range d.dbs {
+		res = append(res, sql.NodeStatsOf(d.nodes[db], db)...)
+	}
+
+	return res
+}
+
+// StatementCacheStats sums the statement cache statistics of every DB,
+// ejected ones included.
+func (d *db) StatementCacheStats() sql.StatementCacheStats {
+	return sql.StatementCacheStatsOf(d.dbs...)
+}
+
+func (d *db) Ping(ctx context.Context) []sql.NodeHealth {
+	var res []sql.NodeHealth
+
+	for _, db := range d.dbs {
+		res = append(res, sql.PingNodes(ctx, d.nodes[db], db)...)
+	}
+
+	return res
+}
+
 func (d *db) BeginTx(ctx context.Context, opts sql.TxOptions) (sql.Tx, error) {
-	db, cfn, err := d.b.Get(ctx)
+	db, cfn, err := d.get(ctx)
 
 	if err != nil {
 		return nil, err
@@ -35,6 +98,7 @@ func (d *db) BeginTx(ctx context.Context, opts sql.TxOptions) (sql.Tx, error) {
 	subTx, err := db.BeginTx(ctx, opts)
 
 	if err != nil {
+		cfn(err)
 		return nil, err
 	}
 
@@ -42,7 +106,7 @@ func (d *db) BeginTx(ctx context.Context, opts sql.TxOptions) (sql.Tx, error) {
 }
 
 func (d *db) Exec(ctx context.Context, q string, vs ...interface{}) (sql.Result, error) {
-	db, cfn, err := d.b.Get(ctx)
+	db, cfn, err := d.get(ctx)
 
 	if err != nil {
 		return nil, err
@@ -62,7 +126,7 @@ type errScanner struct {
 func (esc errScanner) Scan(...interface{}) error { return esc.err }
 
 func (d *db) QueryRow(ctx context.Context, q string, vs ...interface{}) sql.Scanner {
-	db, cfn, err := d.b.Get(ctx)
+	db, cfn, err := d.get(ctx)
 
 	if err != nil {
 		return errScanner{err}
@@ -72,7 +136,7 @@ func (d *db) QueryRow(ctx context.Context, q string, vs ...interface{}) sql.Scan
 }
 
 func (d *db) Query(ctx context.Context, q string, vs ...interface{}) (sql.Cursor, error) {
-	db, cfn, err := d.b.Get(ctx)
+	db, cfn, err := d.get(ctx)
 
 	if err != nil {
 		return nil, err
@@ -81,6 +145,7 @@ func (d *db) Query(ctx context.Context, q string, vs ...interface{}) (sql.Cursor
 	cur, err := db.Query(ctx, q, vs...)
 
 	if err != nil {
+		cfn(err)
 		return nil, err
 	}
 
@@ -103,13 +168,36 @@ func (sc *scanner) Scan(vs ...interface{}) error {
 type cursor struct {
 	sql.Cursor
 
-	cfn CloseFunc
+	cfn     CloseFunc
+	scanErr error
+}
+
+func (c *cursor) Scan(vs ...interface{}) error {
+	err := c.Cursor.Scan(vs...)
+
+	if c.scanErr == nil {
+		c.scanErr = err
+	}
+
+	return err
 }
 
+// Close reports the first error met while iterating over the rows, the one
+// of Close only when the iteration succeeded.
 func (c *cursor) Close() error {
 	err := c.Cursor.Close()
 
-	c.cfn(err)
+	rerr := c.Cursor.Err()
+
+	if rerr == nil {
+		rerr = c.scanErr
+	}
+
+	if rerr == nil {
+		rerr = err
+	}
+
+	c.cfn(rerr)
 
 	return err
 }
@@ -135,3 +223,33 @@ func (tx *tx) Rollback() error {
 
 	return err
 }
+
+func (tx *tx) Savepoint(ctx context.Context, name string) error {
+	sp, ok := tx.Tx.(sql.Savepointer)
+
+	if !ok {
+		return sql.ErrSavepointNotSupported
+	}
+
+	return sp.Savepoint(ctx, name)
+}
+
+func (tx *tx) ReleaseSavepoint(ctx context.Context, name string) error {
+	sp, ok := tx.Tx.(sql.Savepointer)
+
+	if !ok {
+		return sql.ErrSavepointNotSupported
+	}
+
+	return sp.ReleaseSavepoint(ctx, name)
+}
+
+func (tx *tx) RollbackTo(ctx context.Context, name string) error {
+	sp, ok := tx.Tx.(sql.Savepointer)
+
+	if !ok {
+		return sql.ErrSavepointNotSupported
+	}
+
+	return sp.RollbackTo(ctx, name)
+}
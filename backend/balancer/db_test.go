@@ -2,9 +2,12 @@ package balancer
 
 import (
 	"context"
+	"errors"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	"github.com/upfluence/sql"
 	"github.com/upfluence/sql/backend/static"
 )
 
@@ -63,3 +66,45 @@ func TestLeastPending(t *testing.T) {
 		[]static.Query{{Query: "bar"}, {Query: "buz"}},
 	)
 }
+
+func TestRecordRoute(t *testing.T) {
+	var (
+		db1 = static.DB{Queryer: static.Queryer{QueryRowScanner: emptyScanner{}}}
+		db2 = static.DB{Queryer: static.Queryer{QueryRowScanner: emptyScanner{}}}
+
+		db = NewDB(RoundRobinBalancerBuilder, &db1, &db2)
+	)
+
+	for _, want := range []string{"0", "1", "0"} {
+		ctx, rr := sql.WithRouteRecorder(context.Background())
+
+		assert.Nil(t, db.QueryRow(ctx, "foo").Scan())
+		assert.Equal(t, []string{want}, rr.Nodes())
+	}
+}
+
+func TestStatsAndPing(t *testing.T) {
+	var (
+		errPing = errors.New("unreachable")
+
+		db1 = static.DB{DBStats: sql.DBStats{OpenConnections: 1}}
+		db2 = static.DB{DBStats: sql.DBStats{OpenConnections: 2}, PingErr: errPing}
+
+		db = NewDB(RoundRobinBalancerBuilder, &db1, &db2)
+	)
+
+	assert.Equal(
+		t,
+		[]sql.NodeStats{
+			{Node: "0", Stats: sql.DBStats{OpenConnections: 1}},
+			{Node: "1", Stats: sql.DBStats{OpenConnections: 2}},
+		},
+		db.(sql.StatsProvider).Stats(),
+	)
+
+	assert.Equal(
+		t,
+		[]sql.NodeHealth{{Node: "0"}, {Node: "1", Err: errPing}},
+		db.(sql.Pinger).Ping(context.Background()),
+	)
+}
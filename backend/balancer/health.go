@@ -0,0 +1,246 @@
+package balancer
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"net"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/upfluence/errors"
+
+	"github.com/upfluence/sql"
+)
+
+var defaultHealthOptions = healthOptions{
+	failureThreshold: 3,
+	probeInterval:    time.Second,
+	probeTimeout:     time.Second,
+	probeQuery:       "SELECT 1",
+	isConnErr:        IsConnectionError,
+}
+
+// NoHealthyDBError is returned when every DB of the balancer has been
+// ejected.
+type NoHealthyDBError struct {
+	Ejected int
+}
+
+func (e NoHealthyDBError) Error() string {
+	return fmt.Sprintf("balancer: all the %d DBs are ejected", e.Ejected)
+}
+
+// IsConnectionError reports whether the error denotes a broken connection
+// rather than a failure of the query itself. Cancellations and timeouts are
+// not, even though context.DeadlineExceeded implements net.Error.
+func IsConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var (
+		netErr net.Error
+		cErr   sql.ConnectionError
+		steErr sql.StatementTimeoutError
+	)
+
+	if errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, context.Canceled) ||
+		errors.As(err, &steErr) {
+		return false
+	}
+
+	return errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, sql.ErrConnDone) ||
+		errors.As(err, &cErr) ||
+		errors.As(err, &netErr)
+}
+
+type HealthOption func(*healthOptions)
+
+// WithFailureThreshold sets the number of consecutive connection errors
+// after which a DB is ejected.
+func WithFailureThreshold(n int) HealthOption {
+	return func(o *healthOptions) { o.failureThreshold = n }
+}
+
+func WithProbeInterval(d time.Duration) HealthOption {
+	return func(o *healthOptions) { o.probeInterval = d }
+}
+
+func WithProbeTimeout(d time.Duration) HealthOption {
+	return func(o *healthOptions) { o.probeTimeout = d }
+}
+
+// WithProbeQuery sets the query run against an ejected DB to check whether
+// it can be reinstated.
+func WithProbeQuery(q string) HealthOption {
+	return func(o *healthOptions) { o.probeQuery = q }
+}
+
+func WithConnectionErrorCheck(fn func(error) bool) HealthOption {
+	return func(o *healthOptions) { o.isConnErr = fn }
+}
+
+type healthOptions struct {
+	failureThreshold int
+	probeInterval    time.Duration
+	probeTimeout     time.Duration
+	probeQuery       string
+	isConnErr        func(error) bool
+}
+
+// HealthCheckBalancerBuilder wraps the balancers built by bb, the DBs
+// returning consecutive connection errors are ejected from the balancer and
+// probed in the background until they are healthy again.
+func HealthCheckBalancerBuilder(bb BalancerBuilder, opts ...HealthOption) BalancerBuilder {
+	o := defaultHealthOptions
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	return BalancerBuilderFunc(func(dbs []sql.DB) Balancer {
+		hb := healthBalancer{
+			bb:       bb,
+			opts:     o,
+			dbs:      dbs,
+			failures: make(map[sql.DB]int, len(dbs)),
+			ejected:  make(map[sql.DB]struct{}, len(dbs)),
+			closeCh:  make(chan struct{}),
+		}
+
+		hb.rebuild()
+
+		// The probes only reference hb, the finalizer of the returned value
+		// stops them when the balancer is dropped without being closed.
+		chb := &closingHealthBalancer{healthBalancer: &hb}
+		runtime.SetFinalizer(chb, (*closingHealthBalancer).Close)
+
+		return chb
+	})
+}
+
+type closingHealthBalancer struct {
+	*healthBalancer
+}
+
+type healthBalancer struct {
+	bb   BalancerBuilder
+	opts healthOptions
+
+	mu       sync.RWMutex
+	dbs      []sql.DB
+	failures map[sql.DB]int
+	ejected  map[sql.DB]struct{}
+	b        Balancer
+
+	closeOnce sync.Once
+	closeCh   chan struct{}
+}
+
+// Close stops probing the ejected DBs.
+func (hb *healthBalancer) Close() error {
+	hb.closeOnce.Do(func() { close(hb.closeCh) })
+
+	return nil
+}
+
+func (hb *healthBalancer) rebuild() {
+	var healthy []sql.DB
+
+	for _, db := range hb.dbs {
+		if _, ok := hb.ejected[db]; !ok {
+			healthy = append(healthy, db)
+		}
+	}
+
+	if len(healthy) == 0 {
+		hb.b = nil
+		return
+	}
+
+	hb.b = hb.bb.Build(healthy)
+}
+
+func (hb *healthBalancer) Get(ctx context.Context) (sql.DB, CloseFunc, error) {
+	hb.mu.RLock()
+	b := hb.b
+	hb.mu.RUnlock()
+
+	if b == nil {
+		return nil, nil, NoHealthyDBError{Ejected: len(hb.dbs)}
+	}
+
+	db, cfn, err := b.Get(ctx)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return db, func(err error) {
+		cfn(err)
+		hb.report(db, err)
+	}, nil
+}
+
+func (hb *healthBalancer) report(db sql.DB, err error) {
+	hb.mu.Lock()
+	defer hb.mu.Unlock()
+
+	if _, ok := hb.ejected[db]; ok {
+		return
+	}
+
+	if !hb.opts.isConnErr(err) {
+		hb.failures[db] = 0
+		return
+	}
+
+	hb.failures[db]++
+
+	if hb.failures[db] < hb.opts.failureThreshold {
+		return
+	}
+
+	hb.failures[db] = 0
+	hb.ejected[db] = struct{}{}
+	hb.rebuild()
+
+	go hb.probe(db)
+}
+
+func (hb *healthBalancer) ping(db sql.DB) error {
+	ctx, cancel := context.WithTimeout(context.Background(), hb.opts.probeTimeout)
+	defer cancel()
+
+	var v interface{}
+
+	return db.QueryRow(ctx, hb.opts.probeQuery).Scan(&v)
+}
+
+func (hb *healthBalancer) probe(db sql.DB) {
+	t := time.NewTicker(hb.opts.probeInterval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-hb.closeCh:
+			return
+		case <-t.C:
+		}
+
+		if err := hb.ping(db); err != nil {
+			continue
+		}
+
+		hb.mu.Lock()
+		delete(hb.ejected, db)
+		hb.rebuild()
+		hb.mu.Unlock()
+
+		return
+	}
+}
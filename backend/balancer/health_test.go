@@ -0,0 +1,265 @@
+package balancer
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"net"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/upfluence/sql"
+	"github.com/upfluence/sql/backend/static"
+)
+
+type flakyDB struct {
+	sql.DB
+
+	mu    sync.Mutex
+	calls int
+
+	failing atomic.Bool
+}
+
+func (fdb *flakyDB) Driver() string { return "sqltest" }
+
+func (fdb *flakyDB) QueryRow(context.Context, string, ...interface{}) sql.Scanner {
+	fdb.mu.Lock()
+	fdb.calls++
+	fdb.mu.Unlock()
+
+	if fdb.failing.Load() {
+		return errScanner{driver.ErrBadConn}
+	}
+
+	return emptyScanner{}
+}
+
+func (fdb *flakyDB) Query(context.Context, string, ...interface{}) (sql.Cursor, error) {
+	fdb.mu.Lock()
+	fdb.calls++
+	fdb.mu.Unlock()
+
+	if fdb.failing.Load() {
+		return &static.MultipleCursor{ReturnedErr: driver.ErrBadConn}, nil
+	}
+
+	return &static.MultipleCursor{}, nil
+}
+
+func (fdb *flakyDB) callCount() int {
+	fdb.mu.Lock()
+	defer fdb.mu.Unlock()
+
+	return fdb.calls
+}
+
+func TestHealthCheckBalancer(t *testing.T) {
+	for _, bb := range []BalancerBuilder{
+		RoundRobinBalancerBuilder,
+		LeastPendingBalancerBuilder,
+	} {
+		var (
+			db1, db2 flakyDB
+
+			ctx = context.Background()
+			db  = NewDB(
+				HealthCheckBalancerBuilder(
+					bb,
+					WithFailureThreshold(2),
+					WithProbeInterval(time.Millisecond),
+				),
+				&db1,
+				&db2,
+			)
+		)
+
+		db1.failing.Store(true)
+		db2.failing.Store(true)
+
+		for i := 0; i < 4; i++ {
+			assert.ErrorIs(t, db.QueryRow(ctx, "foo").Scan(), driver.ErrBadConn)
+		}
+
+		assert.Equal(
+			t,
+			NoHealthyDBError{Ejected: 2},
+			db.QueryRow(ctx, "foo").Scan(),
+		)
+
+		db1.failing.Store(false)
+
+		assert.Eventually(
+			t,
+			func() bool { return db.QueryRow(ctx, "foo").Scan() == nil },
+			time.Second,
+			time.Millisecond,
+		)
+
+		n1, n2 := db1.callCount(), db2.callCount()
+
+		for i := 0; i < 3; i++ {
+			assert.NoError(t, db.QueryRow(ctx, "foo").Scan())
+		}
+
+		assert.Equal(t, n1+3, db1.callCount())
+		assert.True(t, db2.callCount() >= n2)
+
+		db2.failing.Store(false)
+	}
+}
+
+func TestIsConnectionError(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "nil"},
+		{name: "bad conn", err: driver.ErrBadConn, want: true},
+		{name: "conn done", err: sql.ErrConnDone, want: true},
+		{name: "connection error", err: sql.ConnectionError{Cause: io.EOF}, want: true},
+		{name: "net error", err: &net.OpError{Op: "dial", Err: io.EOF}, want: true},
+		{name: "deadline exceeded", err: context.DeadlineExceeded},
+		{
+			name: "wrapped deadline exceeded",
+			err:  fmt.Errorf("query: %w", context.DeadlineExceeded),
+		},
+		{name: "canceled", err: context.Canceled},
+		{
+			name: "statement timeout",
+			err:  sql.StatementTimeoutError{Timeout: time.Second, Cause: io.EOF},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsConnectionError(tt.err))
+		})
+	}
+}
+
+func TestHealthCheckBalancerClose(t *testing.T) {
+	var (
+		fdb flakyDB
+
+		ctx = context.Background()
+		db  = NewDB(
+			HealthCheckBalancerBuilder(
+				RoundRobinBalancerBuilder,
+				WithFailureThreshold(1),
+				WithProbeInterval(time.Millisecond),
+			),
+			&fdb,
+			&flakyDB{},
+		)
+	)
+
+	fdb.failing.Store(true)
+
+	for i := 0; i < 2; i++ {
+		db.QueryRow(ctx, "foo").Scan()
+	}
+
+	assert.Eventually(
+		t,
+		func() bool { return fdb.callCount() > 2 },
+		time.Second,
+		time.Millisecond,
+	)
+
+	assert.NoError(t, db.(io.Closer).Close())
+	assert.NoError(t, db.(io.Closer).Close())
+
+	time.Sleep(5 * time.Millisecond)
+	n := fdb.callCount()
+	time.Sleep(10 * time.Millisecond)
+
+	assert.Equal(t, n, fdb.callCount())
+}
+
+func TestHealthCheckBalancerCursorError(t *testing.T) {
+	var (
+		db1, db2 flakyDB
+
+		ctx = context.Background()
+		db  = NewDB(
+			HealthCheckBalancerBuilder(
+				RoundRobinBalancerBuilder,
+				WithFailureThreshold(1),
+				WithProbeInterval(time.Hour),
+			),
+			&db1,
+			&db2,
+		)
+	)
+
+	defer db.(io.Closer).Close()
+
+	db1.failing.Store(true)
+
+	for i := 0; i < 2; i++ {
+		cur, err := db.Query(ctx, "foo")
+		assert.NoError(t, err)
+
+		for cur.Next() {
+		}
+
+		assert.NoError(t, cur.Close())
+	}
+
+	n1 := db1.callCount()
+
+	for i := 0; i < 3; i++ {
+		cur, err := db.Query(ctx, "foo")
+		assert.NoError(t, err)
+		assert.NoError(t, cur.Close())
+	}
+
+	assert.Equal(t, n1, db1.callCount())
+}
+
+func TestHealthCheckBalancerFinalizer(t *testing.T) {
+	var (
+		fdb flakyDB
+
+		b = HealthCheckBalancerBuilder(
+			RoundRobinBalancerBuilder,
+			WithFailureThreshold(1),
+			WithProbeInterval(time.Millisecond),
+		).Build([]sql.DB{&fdb, &flakyDB{}})
+
+		hb = b.(*closingHealthBalancer).healthBalancer
+	)
+
+	fdb.failing.Store(true)
+
+	for i := 0; i < 2; i++ {
+		db, cfn, err := b.Get(context.Background())
+		assert.NoError(t, err)
+
+		cfn(db.QueryRow(context.Background(), "foo").Scan())
+	}
+
+	b = nil
+
+	assert.Eventually(
+		t,
+		func() bool {
+			runtime.GC()
+
+			select {
+			case <-hb.closeCh:
+				return true
+			default:
+				return false
+			}
+		},
+		time.Second,
+		time.Millisecond,
+	)
+}
@@ -0,0 +1,302 @@
+package mysql
+
+import (
+	"context"
+	"database/sql/driver"
+	"regexp"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/upfluence/errors"
+
+	"github.com/upfluence/sql"
+	"github.com/upfluence/sql/sqlparser"
+)
+
+var ErrInvalidArgsNumber = errors.New("invalid arg number")
+
+type db struct {
+	*queryer
+
+	db sql.DB
+}
+
+func NewDB(d sql.DB) sql.DB {
+	return &db{queryer: &queryer{q: d}, db: d}
+}
+
+func (db *db) Driver() string { return db.db.Driver() }
+
+func (db *db) Stats() []sql.NodeStats { return sql.NodeStatsOf("", db.db) }
+
+func (db *db) StatementCacheStats() sql.StatementCacheStats {
+	return sql.StatementCacheStatsOf(db.db)
+}
+
+func (db *db) Ping(ctx context.Context) []sql.NodeHealth {
+	return sql.PingNodes(ctx, "", db.db)
+}
+
+func (db *db) BeginTx(ctx context.Context, opts sql.TxOptions) (sql.Tx, error) {
+	dtx, err := db.db.BeginTx(ctx, opts)
+
+	if err != nil {
+		return nil, wrapErr(err)
+	}
+
+	return &tx{queryer: &queryer{q: dtx}, tx: dtx}, nil
+}
+
+type tx struct {
+	*queryer
+
+	tx sql.Tx
+}
+
+func (tx *tx) Commit() error   { return wrapErr(tx.tx.Commit()) }
+func (tx *tx) Rollback() error { return wrapErr(tx.tx.Rollback()) }
+
+func (tx *tx) Savepoint(ctx context.Context, name string) error {
+	return tx.execSavepoint(ctx, "SAVEPOINT", name)
+}
+
+func (tx *tx) ReleaseSavepoint(ctx context.Context, name string) error {
+	return tx.execSavepoint(ctx, "RELEASE SAVEPOINT", name)
+}
+
+func (tx *tx) RollbackTo(ctx context.Context, name string) error {
+	return tx.execSavepoint(ctx, "ROLLBACK TO SAVEPOINT", name)
+}
+
+func (tx *tx) execSavepoint(ctx context.Context, cmd, name string) error {
+	_, err := tx.q.Exec(ctx, cmd+" "+quoteIdentifier(name))
+	return wrapErr(err)
+}
+
+func quoteIdentifier(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+type queryer struct {
+	q sql.Queryer
+}
+
+func deadline(ctx context.Context, vs []interface{}) *sql.StatementDeadline {
+	d, _ := sql.LookupStatementTimeout(ctx, vs)
+
+	return sql.NewStatementDeadline(ctx, d)
+}
+
+// Exec emulates *sql.Returning through the last insert id reported by the
+// server, the returned field must therefore be an AUTO_INCREMENT column.
+func (q *queryer) Exec(ctx context.Context, stmt string, vs ...interface{}) (sql.Result, error) {
+	sd := deadline(ctx, vs)
+	defer sd.Done()
+
+	stmt, vs, err := rewrite(stmt, vs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := q.q.Exec(sd.Context(), stmt, vs...)
+
+	return res, sd.WrapErr(err, wrapErr)
+}
+
+func (q *queryer) QueryRow(ctx context.Context, stmt string, vs ...interface{}) sql.Scanner {
+	sd := deadline(ctx, vs)
+
+	stmt, vs, err := rewrite(stmt, vs)
+
+	if err != nil {
+		sd.Done()
+		return errScanner{err}
+	}
+
+	return &scanner{sc: q.q.QueryRow(sd.Context(), stmt, vs...), sd: sd}
+}
+
+func (q *queryer) Query(ctx context.Context, stmt string, vs ...interface{}) (sql.Cursor, error) {
+	sd := deadline(ctx, vs)
+
+	stmt, vs, err := rewrite(stmt, vs)
+
+	if err != nil {
+		sd.Done()
+		return nil, err
+	}
+
+	cur, err := q.q.Query(sd.Context(), stmt, vs...)
+
+	if err != nil {
+		sd.Done()
+		return nil, sd.WrapErr(err, wrapErr)
+	}
+
+	return &cursor{Cursor: cur, sd: sd}, nil
+}
+
+type scanner struct {
+	sc sql.Scanner
+	sd *sql.StatementDeadline
+}
+
+func (sc *scanner) Scan(vs ...interface{}) error {
+	defer sc.sd.Done()
+
+	return sc.sd.WrapErr(sc.sc.Scan(vs...), wrapErr)
+}
+
+type cursor struct {
+	sql.Cursor
+
+	sd *sql.StatementDeadline
+}
+
+func (c *cursor) Scan(vs ...interface{}) error {
+	return c.sd.WrapErr(c.Cursor.Scan(vs...), wrapErr)
+}
+
+func (c *cursor) Close() error {
+	defer c.sd.Done()
+
+	return c.Cursor.Close()
+}
+
+type errScanner struct {
+	error
+}
+
+func (es errScanner) Scan(...interface{}) error { return es.error }
+
+// rewrite replaces the $n placeholders by ?, since MySQL placeholders are
+// positional the arguments are reordered and duplicated to follow the
+// placeholders of the statement.
+func rewrite(stmt string, vs []interface{}) (string, []interface{}, error) {
+	var (
+		rvs  []interface{}
+		seen = make(map[int]struct{})
+
+		invalid bool
+
+		ovs = vs
+	)
+
+	vs = sql.StripOptions(vs)
+
+	rstmt := sqlparser.RewritePlaceholders(stmt, func(n int) string {
+		if n < 1 || n > len(vs) {
+			invalid = true
+			return "?"
+		}
+
+		seen[n] = struct{}{}
+		rvs = append(rvs, vs[n-1])
+
+		return "?"
+	}, sqlparser.WithBackslashEscapes())
+
+	if invalid || len(seen) != len(vs) {
+		return "", nil, ErrInvalidArgsNumber
+	}
+
+	if !sql.IsStatementCacheable(ovs) {
+		rvs = append(rvs, sql.NoStatementCache{})
+	}
+
+	return rstmt, rvs, nil
+}
+
+const (
+	errDupEntry        = 1062
+	errNoReferencedRow = 1452
+	errBadNull         = 1048
+	errLockDeadlock    = 1213
+	errLockWaitTimeout = 1205
+
+	primaryKeyIndexName = "PRIMARY"
+)
+
+var (
+	dupEntryRegexp   = regexp.MustCompile(`for key '(?:([^']+)\.)?([^']+)'$`)
+	foreignKeyRegexp = regexp.MustCompile(
+		"a foreign key constraint fails \\((?:`[^`]+`\\.)?`([^`]+)`, " +
+			"CONSTRAINT `([^`]+)` FOREIGN KEY \\(`([^`]+)`\\)",
+	)
+	badNullRegexp = regexp.MustCompile(`^Column '([^']+)' cannot be null`)
+)
+
+func wrapErr(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, mysql.ErrInvalidConn) {
+		return sql.ConnectionError{Cause: err}
+	}
+
+	var myErr *mysql.MySQLError
+
+	if !errors.As(err, &myErr) {
+		return err
+	}
+
+	switch myErr.Number {
+	case errDupEntry:
+		return wrapDupEntryError(myErr)
+	case errNoReferencedRow:
+		return wrapForeignKeyError(myErr)
+	case errBadNull:
+		return wrapBadNullError(myErr)
+	case errLockDeadlock:
+		return sql.RollbackError{Cause: myErr, Type: sql.Deadlock}
+	case errLockWaitTimeout:
+		return sql.RollbackError{Cause: myErr, Type: sql.Locked}
+	}
+
+	return err
+}
+
+func wrapDupEntryError(myErr *mysql.MySQLError) error {
+	err := sql.ConstraintError{Type: sql.Unique, Cause: myErr}
+
+	if m := dupEntryRegexp.FindStringSubmatch(myErr.Message); m != nil {
+		err.Table = m[1]
+		err.Constraint = m[2]
+	}
+
+	if err.Constraint == primaryKeyIndexName {
+		err.Type = sql.PrimaryKey
+	}
+
+	return err
+}
+
+func wrapForeignKeyError(myErr *mysql.MySQLError) error {
+	err := sql.ConstraintError{Type: sql.ForeignKey, Cause: myErr}
+
+	if m := foreignKeyRegexp.FindStringSubmatch(myErr.Message); m != nil {
+		err.Table = m[1]
+		err.Constraint = m[2]
+		err.Column = m[3]
+	}
+
+	return err
+}
+
+func wrapBadNullError(myErr *mysql.MySQLError) error {
+	err := sql.ConstraintError{Type: sql.NotNull, Cause: myErr}
+
+	if m := badNullRegexp.FindStringSubmatch(myErr.Message); m != nil {
+		err.Constraint = m[1]
+		err.Column = m[1]
+	}
+
+	return err
+}
+
+func IsMySQLDB(d sql.DB) bool {
+	_, ok := d.(*db)
+	return ok
+}
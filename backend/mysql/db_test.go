@@ -0,0 +1,179 @@
+package mysql
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/upfluence/sql"
+	"github.com/upfluence/sql/backend/static"
+)
+
+func TestQueryer(t *testing.T) {
+	for _, tt := range []struct {
+		in, out static.Query
+		err     error
+	}{
+		{
+			in:  static.Query{Query: "foo", Args: []interface{}{}},
+			out: static.Query{Query: "foo"},
+		},
+		{
+			in:  static.Query{Query: "$1, $2, $3", Args: []interface{}{1, 2, 3}},
+			out: static.Query{Query: "?, ?, ?", Args: []interface{}{1, 2, 3}},
+		},
+		{
+			in:  static.Query{Query: "$2, $3, $1", Args: []interface{}{1, 2, 3}},
+			out: static.Query{Query: "?, ?, ?", Args: []interface{}{2, 3, 1}},
+		},
+		{
+			in:  static.Query{Query: "$1, $2, $1", Args: []interface{}{1, 2}},
+			out: static.Query{Query: "?, ?, ?", Args: []interface{}{1, 2, 1}},
+		},
+		{
+			in: static.Query{
+				Query: "$2, $1 /*trace='a'*/",
+				Args:  []interface{}{1, 2, sql.NoStatementCache{}},
+			},
+			out: static.Query{
+				Query: "?, ? /*trace='a'*/",
+				Args:  []interface{}{2, 1, sql.NoStatementCache{}},
+			},
+		},
+		{
+			in: static.Query{
+				Query: "INSERT INTO foo(bar) VALUES ($1)",
+				Args:  []interface{}{1, &sql.Returning{Field: "id"}},
+			},
+			out: static.Query{
+				Query: "INSERT INTO foo(bar) VALUES (?)",
+				Args:  []interface{}{1},
+			},
+		},
+		{
+			in:  static.Query{Query: `'it\'s $2', $1`, Args: []interface{}{1}},
+			out: static.Query{Query: `'it\'s $2', ?`, Args: []interface{}{1}},
+		},
+		{
+			in:  static.Query{Query: "$2, $1, $4", Args: []interface{}{1, 2, 3}},
+			err: ErrInvalidArgsNumber,
+		},
+		{
+			in:  static.Query{Query: "$2, $1", Args: []interface{}{1, 2, 3}},
+			err: ErrInvalidArgsNumber,
+		},
+	} {
+		t.Run(tt.in.Query, func(t *testing.T) {
+			sq := static.Queryer{}
+			q := queryer{q: &sq}
+			_, err := q.Exec(context.Background(), tt.in.Query, tt.in.Args...)
+			assert.Equal(t, tt.err, err)
+
+			if err == nil {
+				require.Equal(t, 1, len(sq.ExecQueries))
+				rq := sq.ExecQueries[0]
+				assert.Equal(t, tt.out.Query, rq.Query)
+				assert.Equal(t, tt.out.Args, rq.Args)
+			}
+		})
+	}
+}
+
+func TestWrapErr(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		in   error
+		want error
+	}{
+		{
+			name: "primary key",
+			in: &mysql.MySQLError{
+				Number:  1062,
+				Message: "Duplicate entry '1' for key 'foo.PRIMARY'",
+			},
+			want: sql.ConstraintError{
+				Type:       sql.PrimaryKey,
+				Constraint: "PRIMARY",
+				Table:      "foo",
+			},
+		},
+		{
+			name: "unique",
+			in: &mysql.MySQLError{
+				Number:  1062,
+				Message: "Duplicate entry 'bar' for key 'foo_bar_key'",
+			},
+			want: sql.ConstraintError{
+				Type:       sql.Unique,
+				Constraint: "foo_bar_key",
+			},
+		},
+		{
+			name: "foreign key",
+			in: &mysql.MySQLError{
+				Number: 1452,
+				Message: "Cannot add or update a child row: a foreign key " +
+					"constraint fails (`db`.`foo`, CONSTRAINT `foo_bar_fk` " +
+					"FOREIGN KEY (`bar_id`) REFERENCES `bar` (`id`))",
+			},
+			want: sql.ConstraintError{
+				Type:       sql.ForeignKey,
+				Constraint: "foo_bar_fk",
+				Table:      "foo",
+				Column:     "bar_id",
+			},
+		},
+		{
+			name: "not null",
+			in: &mysql.MySQLError{
+				Number:  1048,
+				Message: "Column 'bar' cannot be null",
+			},
+			want: sql.ConstraintError{
+				Type:       sql.NotNull,
+				Constraint: "bar",
+				Column:     "bar",
+			},
+		},
+		{
+			name: "deadlock",
+			in:   &mysql.MySQLError{Number: 1213},
+			want: sql.RollbackError{Type: sql.Deadlock},
+		},
+		{
+			name: "lock wait timeout",
+			in:   &mysql.MySQLError{Number: 1205},
+			want: sql.RollbackError{Type: sql.Locked},
+		},
+		{
+			name: "invalid conn",
+			in:   mysql.ErrInvalidConn,
+			want: sql.ConnectionError{},
+		},
+		{
+			name: "unknown",
+			in:   &mysql.MySQLError{Number: 1064},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			err := wrapErr(tt.in)
+
+			switch want := tt.want.(type) {
+			case sql.ConstraintError:
+				want.Cause = tt.in
+				assert.Equal(t, want, err)
+			case sql.RollbackError:
+				want.Cause = tt.in
+				assert.Equal(t, want, err)
+			case sql.ConnectionError:
+				want.Cause = tt.in
+				assert.Equal(t, want, err)
+			default:
+				assert.Equal(t, tt.in, err)
+			}
+		})
+	}
+}
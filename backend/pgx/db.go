@@ -0,0 +1,35 @@
+package pgx
+
+import (
+	"errors"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	_ "github.com/jackc/pgx/v5/stdlib"
+
+	"github.com/upfluence/sql"
+	"github.com/upfluence/sql/backend/postgres"
+	"github.com/upfluence/sql/sqlparser"
+)
+
+// NewDB wraps a DB opened with the "pgx" driver, it behaves as the one of the
+// postgres backend.
+func NewDB(d sql.DB, p sqlparser.SQLParser) sql.DB {
+	return postgres.NewDriverDB(d, p, extractError)
+}
+
+func extractError(err error) (postgres.Error, bool) {
+	var pgErr *pgconn.PgError
+
+	if !errors.As(err, &pgErr) {
+		return postgres.Error{}, false
+	}
+
+	return postgres.Error{
+		Code:       pgErr.Code,
+		Message:    pgErr.Message,
+		Constraint: pgErr.ConstraintName,
+		Table:      pgErr.TableName,
+		Column:     pgErr.ColumnName,
+		Cause:      pgErr,
+	}, true
+}
@@ -0,0 +1,131 @@
+package pgx
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/upfluence/sql"
+	"github.com/upfluence/sql/backend/static"
+	"github.com/upfluence/sql/sqlparser"
+)
+
+func TestErrorMapping(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		pgErr    *pgconn.PgError
+		canceled bool
+		assert   func(*testing.T, error)
+	}{
+		{
+			name:  "primary key",
+			pgErr: &pgconn.PgError{Code: "23505", ConstraintName: "foo_pkey", TableName: "foo"},
+			assert: func(t *testing.T, err error) {
+				var ce sql.ConstraintError
+
+				assert.True(t, errors.As(err, &ce))
+				assert.Equal(t, sql.PrimaryKey, ce.Type)
+				assert.Equal(t, "foo_pkey", ce.Constraint)
+				assert.Equal(t, "foo", ce.Table)
+			},
+		},
+		{
+			name:  "not null",
+			pgErr: &pgconn.PgError{Code: "23502", TableName: "foo", ColumnName: "bar"},
+			assert: func(t *testing.T, err error) {
+				var ce sql.ConstraintError
+
+				assert.True(t, errors.As(err, &ce))
+				assert.Equal(t, sql.NotNull, ce.Type)
+				assert.Equal(t, "bar", ce.Column)
+			},
+		},
+		{
+			name:  "serialization failure",
+			pgErr: &pgconn.PgError{Code: "40001"},
+			assert: func(t *testing.T, err error) {
+				var re sql.RollbackError
+
+				assert.True(t, errors.As(err, &re))
+				assert.Equal(t, sql.SerializationFailure, re.Type)
+			},
+		},
+		{
+			name:     "query canceled",
+			pgErr:    &pgconn.PgError{Code: "57014", Message: "canceling statement due to user request"},
+			canceled: true,
+			assert: func(t *testing.T, err error) {
+				assert.ErrorIs(t, err, context.Canceled)
+			},
+		},
+		{
+			name:  "statement timeout",
+			pgErr: &pgconn.PgError{Code: "57014", Message: "canceling statement due to statement timeout"},
+			assert: func(t *testing.T, err error) {
+				var ste sql.StatementTimeoutError
+
+				assert.True(t, errors.As(err, &ste))
+				assert.False(t, errors.Is(err, context.Canceled))
+			},
+		},
+		{
+			name:  "admin shutdown",
+			pgErr: &pgconn.PgError{Code: "57P01"},
+			assert: func(t *testing.T, err error) {
+				var ce sql.ConnectionError
+
+				assert.True(t, errors.As(err, &ce))
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			db := NewDB(
+				&static.DB{Queryer: static.Queryer{ExecErr: tt.pgErr}},
+				sqlparser.DefaultSQLParser(),
+			)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			if tt.canceled {
+				cancel()
+			}
+
+			_, err := db.Exec(ctx, "UPDATE foo SET bar = 1")
+
+			tt.assert(t, err)
+		})
+	}
+}
+
+func TestReturning(t *testing.T) {
+	var (
+		sdb = static.DB{
+			Queryer: static.Queryer{
+				QueryRowScanner: &static.Scanner{
+					Args: []static.ScanArg{static.Int64Arg(2)},
+				},
+			},
+		}
+
+		db = NewDB(&sdb, sqlparser.DefaultSQLParser())
+	)
+
+	res, err := db.Exec(
+		context.Background(),
+		"INSERT INTO foo(bar) VALUES ($1)",
+		1,
+		&sql.Returning{Field: "id"},
+	)
+
+	assert.NoError(t, err)
+
+	id, _ := res.LastInsertId()
+	assert.Equal(t, int64(2), id)
+
+	assert.Len(t, sdb.QueryRowQueries, 1)
+	sdb.QueryRowQueries[0].Assert(t, "INSERT INTO foo(bar) VALUES ($1) RETURNING id", 1)
+}
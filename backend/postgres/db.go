@@ -2,9 +2,8 @@ package postgres
 
 import (
 	"context"
-	"errors"
 	"fmt"
-	"strings"
+	"time"
 
 	"github.com/lib/pq"
 
@@ -19,19 +18,46 @@ type db struct {
 }
 
 func NewDB(d sql.DB, p sqlparser.SQLParser) sql.DB {
-	return &db{queryer: &queryer{q: d, p: p}, db: d}
+	return NewDriverDB(d, p, extractPQError)
+}
+
+// NewDriverDB wraps a DB backed by a postgres driver other than lib/pq, fn
+// extracts the server errors out of the errors returned by the driver.
+func NewDriverDB(d sql.DB, p sqlparser.SQLParser, fn ErrorExtractor) sql.DB {
+	return &db{
+		queryer: &queryer{q: d, p: p, em: errorMapper{extract: fn}},
+		db:      d,
+	}
 }
 
 func (db *db) Driver() string { return db.db.Driver() }
 
+func (db *db) Stats() []sql.NodeStats { return sql.NodeStatsOf("", db.db) }
+
+func (db *db) StatementCacheStats() sql.StatementCacheStats {
+	return sql.StatementCacheStatsOf(db.db)
+}
+
+func (db *db) Ping(ctx context.Context) []sql.NodeHealth {
+	return sql.PingNodes(ctx, "", db.db)
+}
+
 func (db *db) BeginTx(ctx context.Context, opts sql.TxOptions) (sql.Tx, error) {
 	cur, err := db.db.BeginTx(ctx, opts)
 
 	if err != nil {
-		return nil, wrapErr(err)
+		return nil, db.em.wrapErr(err)
 	}
 
-	return &tx{queryer: &queryer{q: cur, p: db.p}, tx: cur}, nil
+	return &tx{
+		queryer: &queryer{
+			q:  cur,
+			p:  db.p,
+			em: db.em,
+			lt: newLocalTimeout(cur, db.em),
+		},
+		tx: cur,
+	}, nil
 }
 
 type tx struct {
@@ -40,32 +66,156 @@ type tx struct {
 	tx sql.Tx
 }
 
-func (tx *tx) Commit() error   { return wrapErr(tx.tx.Commit()) }
-func (tx *tx) Rollback() error { return wrapErr(tx.tx.Rollback()) }
+func (tx *tx) Commit() error   { return tx.em.wrapErr(tx.tx.Commit()) }
+func (tx *tx) Rollback() error { return tx.em.wrapErr(tx.tx.Rollback()) }
+
+func (tx *tx) Savepoint(ctx context.Context, name string) error {
+	if err := tx.execSavepoint(ctx, "SAVEPOINT", name); err != nil {
+		return err
+	}
+
+	tx.lt.savepoints[name] = tx.lt.current
+
+	return nil
+}
+
+func (tx *tx) ReleaseSavepoint(ctx context.Context, name string) error {
+	return tx.execSavepoint(ctx, "RELEASE SAVEPOINT", name)
+}
+
+func (tx *tx) RollbackTo(ctx context.Context, name string) error {
+	if err := tx.execSavepoint(ctx, "ROLLBACK TO SAVEPOINT", name); err != nil {
+		return err
+	}
+
+	// Rolling back to a savepoint also reverts the SET LOCAL executed since.
+	if d, ok := tx.lt.savepoints[name]; ok {
+		tx.lt.current = d
+	}
+
+	return nil
+}
+
+func (tx *tx) execSavepoint(ctx context.Context, cmd, name string) error {
+	_, err := tx.q.Exec(ctx, cmd+" "+pq.QuoteIdentifier(name))
+	return tx.em.wrapErr(err)
+}
+
+// localTimeout tracks the statement_timeout of a transaction to only issue
+// a SET LOCAL when the timeout of the statement differs from the previous
+// one. A zero duration stands for the timeout of the session.
+type localTimeout struct {
+	q  sql.Queryer
+	em errorMapper
+
+	current    time.Duration
+	savepoints map[string]time.Duration
+}
+
+func newLocalTimeout(q sql.Queryer, em errorMapper) *localTimeout {
+	return &localTimeout{
+		q:          q,
+		em:         em,
+		savepoints: make(map[string]time.Duration),
+	}
+}
+
+func (lt *localTimeout) set(ctx context.Context, d time.Duration) error {
+	if lt.current == d {
+		return nil
+	}
+
+	stmt := "SET LOCAL statement_timeout TO DEFAULT"
+
+	if d > 0 {
+		stmt = fmt.Sprintf("SET LOCAL statement_timeout = %d", d.Milliseconds())
+	}
+
+	if _, err := lt.q.Exec(ctx, stmt); err != nil {
+		return lt.em.wrapErr(err)
+	}
+
+	lt.current = d
+
+	return nil
+}
 
 type queryer struct {
-	q sql.Queryer
-	p sqlparser.SQLParser
+	q  sql.Queryer
+	p  sqlparser.SQLParser
+	em errorMapper
+	lt *localTimeout
+}
+
+// deadline enforces the statement timeout server side within a transaction
+// and through the deadline of the context otherwise. It also returns the
+// function mapping the errors of the statement.
+func (q *queryer) deadline(ctx context.Context, vs []interface{}) (*sql.StatementDeadline, func(error) error, error) {
+	d, _ := sql.LookupStatementTimeout(ctx, vs)
+
+	if q.lt == nil {
+		return sql.NewStatementDeadline(ctx, d), q.wrapErr(ctx, 0), nil
+	}
+
+	if err := q.lt.set(ctx, d); err != nil {
+		return nil, nil, err
+	}
+
+	return sql.NewStatementDeadline(ctx, 0), q.wrapErr(ctx, d), nil
+}
+
+func (q *queryer) wrapErr(ctx context.Context, d time.Duration) func(error) error {
+	return func(err error) error { return q.em.wrapStatementErr(ctx, d, err) }
 }
 
 func (q *queryer) QueryRow(ctx context.Context, stmt string, vs ...interface{}) sql.Scanner {
-	return &scanner{sc: q.q.QueryRow(ctx, stmt, vs...)}
+	sd, wrapErr, err := q.deadline(ctx, vs)
+
+	if err != nil {
+		return errScanner{err}
+	}
+
+	return &scanner{
+		sc:      q.q.QueryRow(sd.Context(), stmt, vs...),
+		sd:      sd,
+		wrapErr: wrapErr,
+	}
 }
 
 func (q *queryer) Query(ctx context.Context, stmt string, vs ...interface{}) (sql.Cursor, error) {
-	cur, err := q.q.Query(ctx, stmt, vs...)
+	sd, wrapErr, err := q.deadline(ctx, vs)
 
 	if err != nil {
-		return nil, wrapErr(err)
+		return nil, err
 	}
 
-	return &cursor{Cursor: cur}, nil
+	cur, err := q.q.Query(sd.Context(), stmt, vs...)
+
+	if err != nil {
+		sd.Done()
+		return nil, sd.WrapErr(err, wrapErr)
+	}
+
+	return &cursor{Cursor: cur, sd: sd, wrapErr: wrapErr}, nil
 }
 
 func (q *queryer) Exec(ctx context.Context, stmt string, vs ...interface{}) (sql.Result, error) {
+	sd, wrapErr, err := q.deadline(ctx, vs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer sd.Done()
+
+	res, err := q.exec(sd.Context(), stmt, vs)
+
+	return res, sd.WrapErr(err, wrapErr)
+}
+
+func (q *queryer) exec(ctx context.Context, stmt string, vs []interface{}) (sql.Result, error) {
 	if q.p.GetStatementType(stmt) != sqlparser.StmtInsert {
-		res, err := q.q.Exec(ctx, stmt, vs...)
-		return res, wrapErr(err)
+		return q.q.Exec(ctx, stmt, vs...)
 	}
 
 	var (
@@ -89,109 +239,51 @@ func (q *queryer) Exec(ctx context.Context, stmt string, vs ...interface{}) (sql
 			fmt.Sprintf("%s RETURNING %s", stmt, ret.Field),
 			args...,
 		).Scan(&id); err != nil {
-			return nil, wrapErr(err)
+			return nil, err
 		}
 
 		return sql.StaticResult(id), nil
 	}
 
-	res, err := q.q.Exec(ctx, stmt, vs...)
+	return q.q.Exec(ctx, stmt, vs...)
+}
 
-	return res, wrapErr(err)
+type errScanner struct {
+	error
 }
 
+func (es errScanner) Scan(...interface{}) error { return es.error }
+
 type scanner struct {
-	sc sql.Scanner
+	sc      sql.Scanner
+	sd      *sql.StatementDeadline
+	wrapErr func(error) error
 }
 
 func (sc *scanner) Scan(vs ...interface{}) error {
-	return wrapErr(sc.sc.Scan(vs...))
+	defer sc.sd.Done()
+
+	return sc.sd.WrapErr(sc.sc.Scan(vs...), sc.wrapErr)
 }
 
 type cursor struct {
 	sql.Cursor
-}
-
-func (c *cursor) Scan(vs ...interface{}) error {
-	return wrapErr(c.Cursor.Scan(vs...))
-}
-
-func IsPostgresDB(d sql.DB) bool {
-	_, ok := d.(*db)
-	return ok
-}
-
-const (
-	constraintClass = pq.ErrorClass("23")
-	rollbackClass   = pq.ErrorClass("40")
-)
-
-type queryCanceledError struct {
-	cause *pq.Error
-}
-
-func (qce *queryCanceledError) Error() string {
-	return qce.cause.Error()
-}
 
-func (qce *queryCanceledError) Is(target error) bool {
-	return target == context.Canceled
+	sd      *sql.StatementDeadline
+	wrapErr func(error) error
 }
 
-func (qce *queryCanceledError) Unwrap() error { return qce.cause }
-
-func wrapErr(err error) error {
-	if err == nil {
-		return err
-	}
-
-	var pqErr *pq.Error
-
-	if !errors.As(err, &pqErr) {
-		return err
-	}
-
-	if pqErr.Code == "57014" {
-		return &queryCanceledError{cause: pqErr}
-	}
-
-	switch pqErr.Code.Class() {
-	case constraintClass:
-		return wrapConstraintErr(pqErr)
-	case rollbackClass:
-		return wrapRollbackError(pqErr)
-	default:
-		return err
-	}
+func (c *cursor) Scan(vs ...interface{}) error {
+	return c.sd.WrapErr(c.Cursor.Scan(vs...), c.wrapErr)
 }
 
-func wrapRollbackError(pqErr *pq.Error) error {
-	var err = sql.RollbackError{Cause: pqErr}
-
-	if pqErr.Code == "40001" {
-		err.Type = sql.SerializationFailure
-	}
+func (c *cursor) Close() error {
+	defer c.sd.Done()
 
-	return err
+	return c.Cursor.Close()
 }
 
-func wrapConstraintErr(pqErr *pq.Error) error {
-	var err = sql.ConstraintError{Cause: pqErr, Constraint: pqErr.Column}
-
-	switch pqErr.Code {
-	case "23503":
-		err.Type = sql.ForeignKey
-	case "23502":
-		err.Type = sql.NotNull
-	case "23505":
-		if strings.HasSuffix(pqErr.Constraint, "_pkey") {
-			err.Type = sql.PrimaryKey
-		} else {
-			err.Type = sql.Unique
-		}
-
-		err.Constraint = pqErr.Constraint
-	}
-
-	return err
+func IsPostgresDB(d sql.DB) bool {
+	_, ok := d.(*db)
+	return ok
 }
@@ -2,7 +2,13 @@ package postgres
 
 import (
 	"context"
+	"database/sql/driver"
+	"errors"
+	"reflect"
 	"testing"
+	"time"
+
+	"github.com/lib/pq"
 
 	"github.com/upfluence/sql"
 	"github.com/upfluence/sql/backend/static"
@@ -99,3 +105,238 @@ func TestTxExec(t *testing.T) {
 		return tx
 	})
 }
+
+func TestTxSavepoint(t *testing.T) {
+	var (
+		stx = static.Tx{}
+		b   = static.DB{Tx: &stx}
+		ctx = context.Background()
+	)
+
+	tx, err := NewDB(&b, sqlparser.DefaultSQLParser()).BeginTx(ctx, sql.TxOptions{})
+
+	if err != nil {
+		t.Fatalf("db.BeginTx() = %v, want: nil", err)
+	}
+
+	sp, ok := tx.(sql.Savepointer)
+
+	if !ok {
+		t.Fatalf("tx does not implement sql.Savepointer")
+	}
+
+	sp.Savepoint(ctx, "foo")
+	sp.RollbackTo(ctx, "foo")
+	sp.ReleaseSavepoint(ctx, "foo")
+
+	if len(stx.ExecQueries) != 3 {
+		t.Fatalf("len(tx.ExecQueries) = %v, want %v", len(stx.ExecQueries), 3)
+	}
+
+	stx.ExecQueries[0].Assert(t, `SAVEPOINT "foo"`)
+	stx.ExecQueries[1].Assert(t, `ROLLBACK TO SAVEPOINT "foo"`)
+	stx.ExecQueries[2].Assert(t, `RELEASE SAVEPOINT "foo"`)
+}
+
+func TestTxStatementTimeout(t *testing.T) {
+	var (
+		stx = static.Tx{
+			Queryer: static.Queryer{ExecResult: sql.StaticResult(1)},
+		}
+		b   = static.DB{Tx: &stx}
+		ctx = context.Background()
+	)
+
+	tx, err := NewDB(&b, sqlparser.DefaultSQLParser()).BeginTx(ctx, sql.TxOptions{})
+
+	if err != nil {
+		t.Fatalf("db.BeginTx() = %v, want: nil", err)
+	}
+
+	sp := tx.(sql.Savepointer)
+
+	tx.Exec(ctx, "UPDATE foo SET bar = 1")
+	tx.Exec(ctx, "UPDATE foo SET bar = 1", sql.StatementTimeout(time.Second))
+	sp.Savepoint(ctx, "foo")
+	tx.Exec(sql.WithStatementTimeout(ctx, time.Second), "UPDATE foo SET bar = 1")
+	tx.Exec(ctx, "UPDATE foo SET bar = 1", sql.StatementTimeout(2*time.Second))
+	sp.RollbackTo(ctx, "foo")
+	tx.Exec(ctx, "UPDATE foo SET bar = 1")
+
+	want := []string{
+		"UPDATE foo SET bar = 1",
+		"SET LOCAL statement_timeout = 1000",
+		"UPDATE foo SET bar = 1",
+		`SAVEPOINT "foo"`,
+		"UPDATE foo SET bar = 1",
+		"SET LOCAL statement_timeout = 2000",
+		"UPDATE foo SET bar = 1",
+		`ROLLBACK TO SAVEPOINT "foo"`,
+		"SET LOCAL statement_timeout TO DEFAULT",
+		"UPDATE foo SET bar = 1",
+	}
+
+	if len(stx.ExecQueries) != len(want) {
+		t.Fatalf(
+			"len(tx.ExecQueries) = %v, want %v",
+			len(stx.ExecQueries),
+			len(want),
+		)
+	}
+
+	for i, q := range want {
+		if stx.ExecQueries[i].Query != q {
+			t.Errorf("tx.ExecQueries[%d] = %q, want %q", i, stx.ExecQueries[i].Query, q)
+		}
+	}
+}
+
+func TestWrapErrQueryCanceled(t *testing.T) {
+	// The message of the server is localized, it is not looked at.
+	pqErr := &pq.Error{
+		Code:    queryCanceledCode,
+		Message: "annulation de la requête à cause du délai écoulé",
+	}
+
+	canceledCtx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	for _, tt := range []struct {
+		name string
+		ctx  context.Context
+		tx   bool
+
+		wantTimeout bool
+		wantErr     error
+	}{
+		{
+			name:        "session timeout",
+			ctx:         context.Background(),
+			wantTimeout: true,
+			wantErr:     sql.StatementTimeoutError{Cause: pqErr},
+		},
+		{
+			name:        "local timeout",
+			ctx:         context.Background(),
+			tx:          true,
+			wantTimeout: true,
+			wantErr:     sql.StatementTimeoutError{Timeout: time.Second, Cause: pqErr},
+		},
+		{
+			name:    "canceled",
+			ctx:     canceledCtx,
+			wantErr: &queryCanceledError{cause: pqErr},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				stx = static.Tx{
+					Queryer: static.Queryer{
+						ExecResult:      sql.StaticResult(0),
+						QueryRowScanner: static.Scanner{Err: pqErr},
+					},
+				}
+
+				q sql.Queryer = NewDB(
+					&static.DB{Queryer: stx.Queryer, Tx: &stx},
+					sqlparser.DefaultSQLParser(),
+				)
+			)
+
+			if tt.tx {
+				tx, err := q.(sql.DB).BeginTx(tt.ctx, sql.TxOptions{})
+
+				if err != nil {
+					t.Fatalf("db.BeginTx() = %v, want: nil", err)
+				}
+
+				q = tx
+			}
+
+			err := q.QueryRow(tt.ctx, "SELECT 1", sql.StatementTimeout(time.Second)).Scan()
+
+			if !reflect.DeepEqual(err, tt.wantErr) {
+				t.Errorf("Scan() = %#v, want %#v", err, tt.wantErr)
+			}
+
+			var ste sql.StatementTimeoutError
+
+			if ok := errors.As(err, &ste); ok != tt.wantTimeout {
+				t.Errorf("errors.As(StatementTimeoutError) = %v, want %v", ok, tt.wantTimeout)
+			}
+
+			if ok := errors.Is(err, context.Canceled); ok == tt.wantTimeout {
+				t.Errorf("errors.Is(context.Canceled) = %v, want %v", ok, !tt.wantTimeout)
+			}
+		})
+	}
+}
+
+func TestWrapErr(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		in   error
+		want error
+	}{
+		{
+			name: "deadlock",
+			in:   &pq.Error{Code: "40P01"},
+			want: sql.RollbackError{
+				Type:  sql.Deadlock,
+				Cause: &pq.Error{Code: "40P01"},
+			},
+		},
+		{
+			name: "check",
+			in:   &pq.Error{Code: "23514", Constraint: "foo_check", Table: "foo"},
+			want: sql.ConstraintError{
+				Type:       sql.Check,
+				Constraint: "foo_check",
+				Table:      "foo",
+				Cause:      &pq.Error{Code: "23514", Constraint: "foo_check", Table: "foo"},
+			},
+		},
+		{
+			name: "exclusion",
+			in:   &pq.Error{Code: "23P01", Constraint: "foo_excl", Table: "foo"},
+			want: sql.ConstraintError{
+				Type:       sql.Exclusion,
+				Constraint: "foo_excl",
+				Table:      "foo",
+				Cause:      &pq.Error{Code: "23P01", Constraint: "foo_excl", Table: "foo"},
+			},
+		},
+		{
+			name: "not null",
+			in:   &pq.Error{Code: "23502", Table: "foo", Column: "bar"},
+			want: sql.ConstraintError{
+				Type:       sql.NotNull,
+				Constraint: "bar",
+				Table:      "foo",
+				Column:     "bar",
+				Cause:      &pq.Error{Code: "23502", Table: "foo", Column: "bar"},
+			},
+		},
+		{
+			name: "connection failure",
+			in:   &pq.Error{Code: "08006"},
+			want: sql.ConnectionError{Cause: &pq.Error{Code: "08006"}},
+		},
+		{
+			name: "admin shutdown",
+			in:   &pq.Error{Code: "57P01"},
+			want: sql.ConnectionError{Cause: &pq.Error{Code: "57P01"}},
+		},
+		{
+			name: "bad conn",
+			in:   driver.ErrBadConn,
+			want: sql.ConnectionError{Cause: driver.ErrBadConn},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := (errorMapper{extract: extractPQError}).wrapErr(tt.in); !reflect.DeepEqual(err, tt.want) {
+				t.Errorf("wrapErr() = %#v, want %#v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package postgres
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/upfluence/sql"
+)
+
+const (
+	constraintClass = "23"
+	rollbackClass   = "40"
+	connectionClass = "08"
+
+	queryCanceledCode = "57014"
+)
+
+// Error holds the fields of an error reported by the server independently
+// of the driver used to talk to it.
+type Error struct {
+	Code       string
+	Message    string
+	Constraint string
+	Table      string
+	Column     string
+
+	// Cause is the error returned by the driver, it is used as cause of the
+	// errors built out of it.
+	Cause error
+}
+
+// ErrorExtractor extracts the server error out of an error returned by the
+// driver, it returns false when err was not reported by the server.
+type ErrorExtractor func(error) (Error, bool)
+
+func extractPQError(err error) (Error, bool) {
+	var pqErr *pq.Error
+
+	if !errors.As(err, &pqErr) {
+		return Error{}, false
+	}
+
+	return Error{
+		Code:       string(pqErr.Code),
+		Message:    pqErr.Message,
+		Constraint: pqErr.Constraint,
+		Table:      pqErr.Table,
+		Column:     pqErr.Column,
+		Cause:      pqErr,
+	}, true
+}
+
+type queryCanceledError struct {
+	cause error
+}
+
+func (qce *queryCanceledError) Error() string {
+	return qce.cause.Error()
+}
+
+func (qce *queryCanceledError) Is(target error) bool {
+	return target == context.Canceled
+}
+
+func (qce *queryCanceledError) Unwrap() error { return qce.cause }
+
+type errorMapper struct {
+	extract ErrorExtractor
+}
+
+func (em errorMapper) wrapErr(err error) error {
+	return em.wrapStatementErr(context.Background(), 0, err)
+}
+
+// wrapStatementErr maps the error of a statement executed with ctx under a
+// statement_timeout of d, zero when it is not known. The message of a
+// query_canceled error is localized and can not tell a timeout from a
+// cancellation: the error is a cancellation when ctx is done and a statement
+// timeout otherwise.
+func (em errorMapper) wrapStatementErr(ctx context.Context, d time.Duration, err error) error {
+	if err == nil {
+		return err
+	}
+
+	if errors.Is(err, driver.ErrBadConn) {
+		return sql.ConnectionError{Cause: err}
+	}
+
+	pgErr, ok := em.extract(err)
+
+	if !ok {
+		return err
+	}
+
+	switch pgErr.Code {
+	case queryCanceledCode:
+		if ctx.Err() != nil {
+			return &queryCanceledError{cause: pgErr.Cause}
+		}
+
+		return sql.StatementTimeoutError{Timeout: d, Cause: pgErr.Cause}
+	case "57P01", "57P02", "57P03":
+		// admin_shutdown, crash_shutdown and cannot_connect_now
+		return sql.ConnectionError{Cause: pgErr.Cause}
+	}
+
+	switch errorClass(pgErr.Code) {
+	case constraintClass:
+		return wrapConstraintErr(pgErr)
+	case rollbackClass:
+		return wrapRollbackError(pgErr)
+	case connectionClass:
+		return sql.ConnectionError{Cause: pgErr.Cause}
+	default:
+		return err
+	}
+}
+
+func errorClass(code string) string {
+	if len(code) < 2 {
+		return ""
+	}
+
+	return code[:2]
+}
+
+func wrapRollbackError(pgErr Error) error {
+	var err = sql.RollbackError{Cause: pgErr.Cause}
+
+	switch pgErr.Code {
+	case "40001":
+		err.Type = sql.SerializationFailure
+	case "40P01":
+		err.Type = sql.Deadlock
+	}
+
+	return err
+}
+
+func wrapConstraintErr(pgErr Error) error {
+	var err = sql.ConstraintError{
+		Cause:      pgErr.Cause,
+		Constraint: pgErr.Column,
+		Table:      pgErr.Table,
+		Column:     pgErr.Column,
+	}
+
+	switch pgErr.Code {
+	case "23503":
+		err.Type = sql.ForeignKey
+	case "23502":
+		err.Type = sql.NotNull
+	case "23505":
+		if strings.HasSuffix(pgErr.Constraint, "_pkey") {
+			err.Type = sql.PrimaryKey
+		} else {
+			err.Type = sql.Unique
+		}
+
+		err.Constraint = pgErr.Constraint
+	case "23514":
+		err.Type = sql.Check
+		err.Constraint = pgErr.Constraint
+	case "23P01":
+		err.Type = sql.Exclusion
+		err.Constraint = pgErr.Constraint
+	}
+
+	return err
+}
@@ -2,44 +2,264 @@ package replication
 
 import (
 	"context"
+	"time"
 
 	"github.com/upfluence/sql"
 	"github.com/upfluence/sql/sqlparser"
 )
 
-func NewDB(master sql.DB, slave sql.DB, parser sqlparser.SQLParser) sql.DB {
-	return &db{DB: master, slave: slave, parser: parser}
+type Option func(*db)
+
+// WithLagMonitor sends the reads to the master whenever the monitor has no
+// fresh replica. The slave is watched by the monitor unless it already
+// watches some replicas.
+func WithLagMonitor(m *LagMonitor) Option {
+	return func(d *db) { d.monitor = m }
+}
+
+// WithReadYourWrites makes the reads carrying a sql.Session go to the master
+// for the given window after the session issued a write. When a lag monitor
+// is configured the reads go back to the replicas as soon as they replayed
+// the write.
+func WithReadYourWrites(window time.Duration) Option {
+	return func(d *db) { d.stickyWindow = window }
+}
+
+func NewDB(master sql.DB, slave sql.DB, parser sqlparser.SQLParser, opts ...Option) sql.DB {
+	d := db{DB: master, slave: slave, parser: parser}
+
+	for _, opt := range opts {
+		opt(&d)
+	}
+
+	if d.monitor != nil && !d.monitor.watches() {
+		d.monitor.Watch(slaveRoute, slave)
+	}
+
+	return &d
 }
 
 type db struct {
 	sql.DB
 
-	slave  sql.DB
-	parser sqlparser.SQLParser
+	slave   sql.DB
+	parser  sqlparser.SQLParser
+	monitor *LagMonitor
+
+	stickyWindow time.Duration
+}
+
+func (d *db) Stats() []sql.NodeStats {
+	return append(
+		sql.NodeStatsOf(masterRoute, d.DB),
+		sql.NodeStatsOf(slaveRoute, d.slave)...,
+	)
+}
+
+func (d *db) StatementCacheStats() sql.StatementCacheStats {
+	return sql.StatementCacheStatsOf(d.DB, d.slave)
+}
+
+func (d *db) Ping(ctx context.Context) []sql.NodeHealth {
+	return append(
+		sql.PingNodes(ctx, masterRoute, d.DB),
+		sql.PingNodes(ctx, slaveRoute, d.slave)...,
+	)
+}
+
+func (d *db) slaveIsStale() bool {
+	return d.monitor != nil && !d.monitor.HasFreshReplica()
+}
+
+const (
+	masterRoute = "master"
+	slaveRoute  = "replica"
+)
+
+// session returns the session to mark once a write succeeds.
+func (d *db) session(ctx context.Context) (*sql.Session, bool) {
+	if d.stickyWindow <= 0 {
+		return nil, false
+	}
+
+	return sql.SessionFromContext(ctx)
+}
+
+func markWrite(s *sql.Session, err error) {
+	if err == nil {
+		s.MarkWrite(time.Now())
+	}
+}
+
+func (d *db) readsOwnWrite(ctx context.Context) bool {
+	if d.stickyWindow <= 0 {
+		return false
+	}
+
+	s, ok := sql.SessionFromContext(ctx)
+
+	if !ok {
+		return false
+	}
+
+	lw := s.LastWrite()
+
+	if lw.IsZero() || time.Since(lw) > d.stickyWindow {
+		return false
+	}
+
+	return d.monitor == nil || d.monitor.ReplayedUntil().Before(lw)
+}
+
+// pickDB returns the DB serving the statement and whether the statement
+// writes.
+func (d *db) pickDB(ctx context.Context, q string, vs []interface{}) (sql.DB, bool) {
+	if !sqlparser.Parse(d.parser, q).ReadOnly {
+		sql.RecordRoute(ctx, masterRoute)
+		return d.DB, true
+	}
+
+	if sql.IsStronglyConsistent(ctx, vs) || d.slaveIsStale() || d.readsOwnWrite(ctx) {
+		sql.RecordRoute(ctx, masterRoute)
+		return d.DB, false
+	}
+
+	sql.RecordRoute(ctx, slaveRoute)
+	return d.slave, false
 }
 
-func (d *db) pickDB(q string, vs []interface{}) sql.DB {
-	if sqlparser.IsDML(d.parser.GetStatementType(q)) || forceMaster(vs) {
-		return d.DB
+func (d *db) Exec(ctx context.Context, q string, vs ...interface{}) (sql.Result, error) {
+	sql.RecordRoute(ctx, masterRoute)
+
+	res, err := d.DB.Exec(ctx, q, vs...)
+
+	if s, ok := d.session(ctx); ok {
+		markWrite(s, err)
 	}
 
-	return d.slave
+	return res, err
+}
+
+// BeginTx considers the transactions as writes since the statements
+// executed within them are not visible to the replication layer, the session
+// is marked once the transaction commits.
+func (d *db) BeginTx(ctx context.Context, opts sql.TxOptions) (sql.Tx, error) {
+	sql.RecordRoute(ctx, masterRoute)
+
+	t, err := d.DB.BeginTx(ctx, opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	s, ok := d.session(ctx)
+
+	if !ok {
+		return t, nil
+	}
+
+	return &tx{Tx: t, s: s}, nil
+}
+
+type tx struct {
+	sql.Tx
+
+	s *sql.Session
+}
+
+func (t *tx) Commit() error {
+	err := t.Tx.Commit()
+
+	markWrite(t.s, err)
+
+	return err
+}
+
+func (t *tx) Savepoint(ctx context.Context, name string) error {
+	sp, ok := t.Tx.(sql.Savepointer)
+
+	if !ok {
+		return sql.ErrSavepointNotSupported
+	}
+
+	return sp.Savepoint(ctx, name)
+}
+
+func (t *tx) ReleaseSavepoint(ctx context.Context, name string) error {
+	sp, ok := t.Tx.(sql.Savepointer)
+
+	if !ok {
+		return sql.ErrSavepointNotSupported
+	}
+
+	return sp.ReleaseSavepoint(ctx, name)
+}
+
+func (t *tx) RollbackTo(ctx context.Context, name string) error {
+	sp, ok := t.Tx.(sql.Savepointer)
+
+	if !ok {
+		return sql.ErrSavepointNotSupported
+	}
+
+	return sp.RollbackTo(ctx, name)
 }
 
 func (d *db) QueryRow(ctx context.Context, q string, vs ...interface{}) sql.Scanner {
-	return d.pickDB(q, vs).QueryRow(ctx, q, vs...)
+	db, write := d.pickDB(ctx, q, vs)
+	sc := db.QueryRow(ctx, q, vs...)
+
+	if s, ok := d.session(ctx); ok && write {
+		return &scanner{sc: sc, s: s}
+	}
+
+	return sc
 }
 
 func (d *db) Query(ctx context.Context, q string, vs ...interface{}) (sql.Cursor, error) {
-	return d.pickDB(q, vs).Query(ctx, q, vs...)
+	db, write := d.pickDB(ctx, q, vs)
+	cur, err := db.Query(ctx, q, vs...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if s, ok := d.session(ctx); ok && write {
+		return &cursor{Cursor: cur, s: s}, nil
+	}
+
+	return cur, nil
+}
+
+type scanner struct {
+	sc sql.Scanner
+	s  *sql.Session
 }
 
-func forceMaster(vs []interface{}) bool {
-	for _, v := range vs {
-		if c, ok := v.(sql.Consistency); ok && c == sql.StronglyConsistent {
-			return true
-		}
+func (sc *scanner) Scan(vs ...interface{}) error {
+	err := sc.sc.Scan(vs...)
+
+	markWrite(sc.s, err)
+
+	return err
+}
+
+// cursor marks the session once the rows of the writing statement are
+// consumed without error.
+type cursor struct {
+	sql.Cursor
+
+	s *sql.Session
+}
+
+func (c *cursor) Close() error {
+	err := c.Cursor.Close()
+
+	if cerr := c.Cursor.Err(); cerr != nil {
+		markWrite(c.s, cerr)
+	} else {
+		markWrite(c.s, err)
 	}
 
-	return false
+	return err
 }
@@ -3,10 +3,13 @@ package replication
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/upfluence/errors"
 
 	"github.com/upfluence/sql"
+	"github.com/upfluence/sql/backend/balancer"
 	"github.com/upfluence/sql/backend/static"
 	"github.com/upfluence/sql/sqlparser"
 )
@@ -31,10 +34,11 @@ func (p mockParser) GetStatementType(q string) sqlparser.StmtType {
 
 func TestPickDB(t *testing.T) {
 	tests := []struct {
-		name       string
-		args       []interface{}
-		stype      sqlparser.StmtType
-		wantMaster bool
+		name        string
+		args        []interface{}
+		consistency sql.Consistency
+		stype       sqlparser.StmtType
+		wantMaster  bool
 	}{
 		{
 			name:       "select",
@@ -52,6 +56,12 @@ func TestPickDB(t *testing.T) {
 			stype:      sqlparser.StmtSelect,
 			wantMaster: true,
 		},
+		{
+			name:        "strongly consistent context",
+			consistency: sql.StronglyConsistent,
+			stype:       sqlparser.StmtSelect,
+			wantMaster:  true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -68,9 +78,271 @@ func TestPickDB(t *testing.T) {
 				}
 			)
 
-			db.Query(context.Background(), "foo", tt.args...)
+			db.Query(
+				sql.WithConsistency(context.Background(), tt.consistency),
+				"foo",
+				tt.args...,
+			)
+			assert.Equal(t, tt.wantMaster, db0.called)
+			assert.Equal(t, !tt.wantMaster, db1.called)
+		})
+	}
+}
+
+func TestPickDBDefaultParser(t *testing.T) {
+	for _, tt := range []struct {
+		stmt       string
+		wantMaster bool
+	}{
+		{stmt: "WITH x AS (SELECT 1) SELECT * FROM x"},
+		{stmt: "SELECT * FROM foo FOR UPDATE", wantMaster: true},
+		{stmt: "SELECT nextval('foo_id_seq')", wantMaster: true},
+		{stmt: "SELECT * INTO foo_copy FROM foo", wantMaster: true},
+		{stmt: "WITH x AS (DELETE FROM foo RETURNING *) SELECT * FROM x", wantMaster: true},
+	} {
+		t.Run(tt.stmt, func(t *testing.T) {
+			var (
+				db0, db1 mockDB
+
+				db = NewDB(&db0, &db1, sqlparser.DefaultSQLParser())
+			)
+
+			db.Query(context.Background(), tt.stmt)
 			assert.Equal(t, tt.wantMaster, db0.called)
 			assert.Equal(t, !tt.wantMaster, db1.called)
 		})
 	}
 }
+
+func TestReadYourWrites(t *testing.T) {
+	var (
+		master, slave routeDB
+
+		window = 50 * time.Millisecond
+		db     = NewDB(
+			&master,
+			&slave,
+			sqlparser.DefaultSQLParser(),
+			WithReadYourWrites(window),
+		)
+
+		s   = sql.NewSession()
+		ctx = sql.WithSession(context.Background(), s)
+	)
+
+	route := func(ctx context.Context) string {
+		ctx, rr := sql.WithRouteRecorder(ctx)
+
+		db.Query(ctx, "SELECT 1")
+
+		return rr.Nodes()[0]
+	}
+
+	assert.Equal(t, "replica", route(ctx))
+
+	db.Exec(ctx, "UPDATE foo SET bar = 1")
+
+	assert.Equal(t, "master", route(ctx))
+	assert.Equal(t, "replica", route(context.Background()))
+
+	rs, err := sql.ParseSessionToken(s.Token())
+	assert.NoError(t, err)
+	assert.Equal(t, "master", route(sql.WithSession(context.Background(), rs)))
+
+	time.Sleep(window)
+
+	assert.Equal(t, "replica", route(ctx))
+}
+
+func TestMarkWrite(t *testing.T) {
+	errFailed := errors.New("failed")
+
+	for _, tt := range []struct {
+		name     string
+		master   static.DB
+		fn       func(context.Context, sql.DB) error
+		wantMark bool
+	}{
+		{
+			name:   "exec",
+			master: static.DB{Queryer: static.Queryer{ExecResult: sql.StaticResult(1)}},
+			fn: func(ctx context.Context, db sql.DB) error {
+				_, err := db.Exec(ctx, "UPDATE foo SET bar = 1")
+				return err
+			},
+			wantMark: true,
+		},
+		{
+			name:   "exec failure",
+			master: static.DB{Queryer: static.Queryer{ExecErr: errFailed}},
+			fn: func(ctx context.Context, db sql.DB) error {
+				_, err := db.Exec(ctx, "UPDATE foo SET bar = 1")
+				return err
+			},
+		},
+		{
+			name: "returning",
+			master: static.DB{
+				Queryer: static.Queryer{QueryRowScanner: static.Scanner{}},
+			},
+			fn: func(ctx context.Context, db sql.DB) error {
+				return db.QueryRow(ctx, "DELETE FROM foo RETURNING id").Scan()
+			},
+			wantMark: true,
+		},
+		{
+			name: "returning failure",
+			master: static.DB{
+				Queryer: static.Queryer{
+					QueryRowScanner: static.Scanner{Err: errFailed},
+				},
+			},
+			fn: func(ctx context.Context, db sql.DB) error {
+				return db.QueryRow(ctx, "DELETE FROM foo RETURNING id").Scan()
+			},
+		},
+		{
+			name:   "commit",
+			master: static.DB{Tx: &static.Tx{}},
+			fn: func(ctx context.Context, db sql.DB) error {
+				tx, err := db.BeginTx(ctx, sql.TxOptions{})
+
+				if err != nil {
+					return err
+				}
+
+				return tx.Commit()
+			},
+			wantMark: true,
+		},
+		{
+			name:   "commit failure",
+			master: static.DB{Tx: &static.Tx{CommitErr: errFailed}},
+			fn: func(ctx context.Context, db sql.DB) error {
+				tx, err := db.BeginTx(ctx, sql.TxOptions{})
+
+				if err != nil {
+					return err
+				}
+
+				return tx.Commit()
+			},
+		},
+		{
+			name:   "rollback",
+			master: static.DB{Tx: &static.Tx{}},
+			fn: func(ctx context.Context, db sql.DB) error {
+				tx, err := db.BeginTx(ctx, sql.TxOptions{})
+
+				if err != nil {
+					return err
+				}
+
+				return tx.Rollback()
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				slave static.DB
+
+				s   = sql.NewSession()
+				ctx = sql.WithSession(context.Background(), s)
+				db  = NewDB(
+					&tt.master,
+					&slave,
+					sqlparser.DefaultSQLParser(),
+					WithReadYourWrites(time.Hour),
+				)
+			)
+
+			err := tt.fn(ctx, db)
+
+			if tt.wantMark {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.wantMark, !s.LastWrite().IsZero())
+		})
+	}
+}
+
+func TestReadYourWritesCaughtUp(t *testing.T) {
+	var (
+		master, slave routeDB
+
+		p = staticLagProbe{lags: map[sql.DB]time.Duration{&slave: time.Hour}}
+		m = NewLagMonitor(
+			WithLagProbe(&p),
+			WithMaxLag(2*time.Hour),
+			WithProbeInterval(time.Millisecond),
+		)
+
+		db = NewDB(
+			&master,
+			&slave,
+			sqlparser.DefaultSQLParser(),
+			WithLagMonitor(m),
+			WithReadYourWrites(time.Hour),
+		)
+
+		ctx = sql.WithSession(context.Background(), sql.NewSession())
+	)
+
+	defer m.Close()
+
+	route := func() string {
+		ctx, rr := sql.WithRouteRecorder(ctx)
+
+		db.QueryRow(ctx, "SELECT 1")
+
+		return rr.Nodes()[0]
+	}
+
+	db.Exec(ctx, "UPDATE foo SET bar = 1")
+
+	assert.Equal(t, "master", route())
+
+	p.set(&slave, 0)
+
+	assert.Eventually(
+		t,
+		func() bool { return route() == "replica" },
+		time.Second,
+		time.Millisecond,
+	)
+}
+
+func TestStats(t *testing.T) {
+	var (
+		master = static.DB{DBStats: sql.DBStats{InUse: 3}}
+		s1     = static.DB{DBStats: sql.DBStats{Idle: 1}}
+		s2     = static.DB{DBStats: sql.DBStats{Idle: 2}}
+
+		db = NewDB(
+			&master,
+			balancer.NewDB(balancer.RoundRobinBalancerBuilder, &s1, &s2),
+			sqlparser.DefaultSQLParser(),
+		)
+	)
+
+	assert.Equal(
+		t,
+		[]sql.NodeStats{
+			{Node: "master", Stats: sql.DBStats{InUse: 3}},
+			{Node: "replica/0", Stats: sql.DBStats{Idle: 1}},
+			{Node: "replica/1", Stats: sql.DBStats{Idle: 2}},
+		},
+		db.(sql.StatsProvider).Stats(),
+	)
+
+	assert.Equal(
+		t,
+		[]sql.NodeHealth{
+			{Node: "master"},
+			{Node: "replica/0"},
+			{Node: "replica/1"},
+		},
+		db.(sql.Pinger).Ping(context.Background()),
+	)
+}
@@ -0,0 +1,300 @@
+package replication
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/upfluence/sql"
+	"github.com/upfluence/sql/backend/balancer"
+)
+
+const postgresLagStmt = `
+SELECT CASE
+	WHEN NOT pg_is_in_recovery() THEN 0
+	WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+	ELSE COALESCE(EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp()), 0)
+END`
+
+// LagProbe measures how far behind the master the given replica is.
+type LagProbe interface {
+	Lag(context.Context, sql.DB) (time.Duration, error)
+}
+
+type LagProbeFunc func(context.Context, sql.DB) (time.Duration, error)
+
+func (fn LagProbeFunc) Lag(ctx context.Context, db sql.DB) (time.Duration, error) {
+	return fn(ctx, db)
+}
+
+// PostgresLagProbe relies on pg_last_xact_replay_timestamp(), a replica
+// having replayed all the WAL it received is considered up to date.
+var PostgresLagProbe LagProbe = LagProbeFunc(
+	func(ctx context.Context, db sql.DB) (time.Duration, error) {
+		var secs float64
+
+		if err := db.QueryRow(
+			ctx,
+			postgresLagStmt,
+			sql.StronglyConsistent,
+		).Scan(&secs); err != nil {
+			return 0, err
+		}
+
+		return time.Duration(secs * float64(time.Second)), nil
+	},
+)
+
+const (
+	defaultMaxLag        = 5 * time.Second
+	defaultProbeInterval = time.Second
+	defaultProbeTimeout  = time.Second
+)
+
+type LagMonitorOption func(*LagMonitor)
+
+func WithLagProbe(p LagProbe) LagMonitorOption {
+	return func(m *LagMonitor) { m.probe = p }
+}
+
+// WithMaxLag sets the lag above which a replica stops receiving reads.
+func WithMaxLag(d time.Duration) LagMonitorOption {
+	return func(m *LagMonitor) { m.maxLag = d }
+}
+
+func WithProbeInterval(d time.Duration) LagMonitorOption {
+	return func(m *LagMonitor) { m.interval = d }
+}
+
+func WithProbeTimeout(d time.Duration) LagMonitorOption {
+	return func(m *LagMonitor) { m.timeout = d }
+}
+
+type replicaState struct {
+	name  string
+	lag   time.Duration
+	fresh bool
+
+	replayedAt time.Time
+}
+
+// LagMonitor periodically measures the lag of the watched replicas. A
+// replica whose lag exceeds the configured bound, or which can not be
+// probed, is considered stale until the next successful measurement.
+type LagMonitor struct {
+	probe    LagProbe
+	maxLag   time.Duration
+	interval time.Duration
+	timeout  time.Duration
+
+	mu       sync.RWMutex
+	replicas map[sql.DB]*replicaState
+	version  uint64
+
+	closeOnce sync.Once
+	closeCh   chan struct{}
+}
+
+func NewLagMonitor(opts ...LagMonitorOption) *LagMonitor {
+	m := LagMonitor{
+		probe:    PostgresLagProbe,
+		maxLag:   defaultMaxLag,
+		interval: defaultProbeInterval,
+		timeout:  defaultProbeTimeout,
+		replicas: make(map[sql.DB]*replicaState),
+		closeCh:  make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(&m)
+	}
+
+	return &m
+}
+
+// Watch starts monitoring the replica, it is considered fresh until its
+// first measurement.
+func (m *LagMonitor) Watch(name string, db sql.DB) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.replicas[db]; ok {
+		return
+	}
+
+	m.replicas[db] = &replicaState{name: name, fresh: true}
+
+	go m.run(db)
+}
+
+func (m *LagMonitor) watches() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return len(m.replicas) > 0
+}
+
+func (m *LagMonitor) Close() error {
+	m.closeOnce.Do(func() { close(m.closeCh) })
+
+	return nil
+}
+
+func (m *LagMonitor) run(db sql.DB) {
+	t := time.NewTicker(m.interval)
+	defer t.Stop()
+
+	for {
+		m.measure(db)
+
+		select {
+		case <-m.closeCh:
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func (m *LagMonitor) measure(db sql.DB) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+	defer cancel()
+
+	t0 := time.Now()
+	lag, err := m.probe.Lag(ctx, db)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	st := m.replicas[db]
+
+	fresh := err == nil && lag <= m.maxLag
+
+	if err == nil {
+		st.lag = lag
+		st.replayedAt = t0.Add(-lag)
+	}
+
+	if fresh != st.fresh {
+		st.fresh = fresh
+		atomic.AddUint64(&m.version, 1)
+	}
+}
+
+// Lags returns the last lag measured for each replica, keyed by name.
+func (m *LagMonitor) Lags() map[string]time.Duration {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	res := make(map[string]time.Duration, len(m.replicas))
+
+	for _, st := range m.replicas {
+		res[st.name] = st.lag
+	}
+
+	return res
+}
+
+// IsFresh reports whether the replica can serve reads, DBs not watched by
+// the monitor are always considered fresh.
+func (m *LagMonitor) IsFresh(db sql.DB) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	st, ok := m.replicas[db]
+
+	return !ok || st.fresh
+}
+
+// HasFreshReplica reports whether at least one watched replica can serve
+// reads.
+func (m *LagMonitor) HasFreshReplica() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, st := range m.replicas {
+		if st.fresh {
+			return true
+		}
+	}
+
+	return len(m.replicas) == 0
+}
+
+// ReplayedUntil returns the point in time up to which every fresh replica
+// is known to have replayed the master writes.
+func (m *LagMonitor) ReplayedUntil() time.Time {
+	var res time.Time
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, st := range m.replicas {
+		if !st.fresh {
+			continue
+		}
+
+		if st.replayedAt.IsZero() {
+			return time.Time{}
+		}
+
+		if res.IsZero() || st.replayedAt.Before(res) {
+			res = st.replayedAt
+		}
+	}
+
+	return res
+}
+
+func (m *LagMonitor) freshDBs(dbs []sql.DB) []sql.DB {
+	var res []sql.DB
+
+	for _, db := range dbs {
+		if m.IsFresh(db) {
+			res = append(res, db)
+		}
+	}
+
+	return res
+}
+
+// FreshBalancerBuilder restricts the balancers built by bb to the replicas
+// the monitor considers fresh.
+func FreshBalancerBuilder(m *LagMonitor, bb balancer.BalancerBuilder) balancer.BalancerBuilder {
+	return balancer.BalancerBuilderFunc(func(dbs []sql.DB) balancer.Balancer {
+		return &freshBalancer{m: m, bb: bb, dbs: dbs, version: ^uint64(0)}
+	})
+}
+
+type freshBalancer struct {
+	m   *LagMonitor
+	bb  balancer.BalancerBuilder
+	dbs []sql.DB
+
+	mu      sync.Mutex
+	version uint64
+	b       balancer.Balancer
+}
+
+func (fb *freshBalancer) Get(ctx context.Context) (sql.DB, balancer.CloseFunc, error) {
+	fb.mu.Lock()
+
+	if v := atomic.LoadUint64(&fb.m.version); v != fb.version {
+		fb.version = v
+		fb.b = nil
+
+		if dbs := fb.m.freshDBs(fb.dbs); len(dbs) > 0 {
+			fb.b = fb.bb.Build(dbs)
+		}
+	}
+
+	b := fb.b
+
+	fb.mu.Unlock()
+
+	if b == nil {
+		return nil, nil, balancer.NoHealthyDBError{Ejected: len(fb.dbs)}
+	}
+
+	return b.Get(ctx)
+}
@@ -0,0 +1,125 @@
+package replication
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/upfluence/sql"
+	"github.com/upfluence/sql/backend/balancer"
+	"github.com/upfluence/sql/backend/static"
+	"github.com/upfluence/sql/sqlparser"
+)
+
+type staticLagProbe struct {
+	mu   sync.Mutex
+	lags map[sql.DB]time.Duration
+}
+
+func (p *staticLagProbe) set(db sql.DB, d time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.lags[db] = d
+}
+
+func (p *staticLagProbe) Lag(_ context.Context, db sql.DB) (time.Duration, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.lags[db], nil
+}
+
+type routeDB struct {
+	static.DB
+}
+
+func TestLagMonitor(t *testing.T) {
+	var (
+		master, slave routeDB
+
+		p = staticLagProbe{lags: make(map[sql.DB]time.Duration)}
+		m = NewLagMonitor(
+			WithLagProbe(&p),
+			WithMaxLag(time.Second),
+			WithProbeInterval(time.Millisecond),
+		)
+
+		db = NewDB(&master, &slave, sqlparser.DefaultSQLParser(), WithLagMonitor(m))
+	)
+
+	defer m.Close()
+
+	route := func() []string {
+		ctx, rr := sql.WithRouteRecorder(context.Background())
+
+		db.Query(ctx, "SELECT 1")
+
+		return rr.Nodes()
+	}
+
+	assert.Equal(t, []string{"replica"}, route())
+
+	p.set(&slave, time.Minute)
+
+	assert.Eventually(
+		t,
+		func() bool { return route()[0] == "master" },
+		time.Second,
+		time.Millisecond,
+	)
+	assert.Equal(t, map[string]time.Duration{"replica": time.Minute}, m.Lags())
+
+	p.set(&slave, 0)
+
+	assert.Eventually(
+		t,
+		func() bool { return route()[0] == "replica" },
+		time.Second,
+		time.Millisecond,
+	)
+}
+
+func TestFreshBalancerBuilder(t *testing.T) {
+	var (
+		r1, r2 routeDB
+
+		ctx = context.Background()
+		p   = staticLagProbe{
+			lags: map[sql.DB]time.Duration{&r1: time.Minute},
+		}
+		m = NewLagMonitor(
+			WithLagProbe(&p),
+			WithMaxLag(time.Second),
+			WithProbeInterval(time.Millisecond),
+		)
+
+		db = balancer.NewDB(
+			FreshBalancerBuilder(m, balancer.RoundRobinBalancerBuilder),
+			&r1,
+			&r2,
+		)
+	)
+
+	defer m.Close()
+
+	m.Watch("r1", &r1)
+	m.Watch("r2", &r2)
+
+	assert.Eventually(
+		t,
+		func() bool { return !m.IsFresh(&r1) },
+		time.Second,
+		time.Millisecond,
+	)
+
+	for i := 0; i < 4; i++ {
+		db.Query(ctx, "SELECT 1")
+	}
+
+	assert.Len(t, r1.QueryQueries, 0)
+	assert.Len(t, r2.QueryQueries, 4)
+}
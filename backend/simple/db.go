@@ -3,30 +3,67 @@ package simple
 import (
 	"context"
 	stdsql "database/sql"
+	"strings"
 	"sync"
 
 	"github.com/upfluence/sql"
 )
 
+type options struct {
+	stmtCacheSize int
+}
+
+type Option func(*options)
+
+// WithStatementCache prepares the statements executed through the DB and
+// keeps the size most recently used ones, the evicted statements are closed.
+func WithStatementCache(size int) Option {
+	return func(o *options) { o.stmtCacheSize = size }
+}
+
 type db struct {
 	*queryer
 
 	db     *stdsql.DB
 	driver string
+	cache  *stmtCache
 }
 
-func FromStdDB(stdDB *stdsql.DB, driver string) sql.DB {
-	return &db{queryer: &queryer{stdDB}, db: stdDB, driver: driver}
+func FromStdDB(stdDB *stdsql.DB, driver string, opts ...Option) sql.DB {
+	var o options
+
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	d := db{queryer: &queryer{q: stdDB}, db: stdDB, driver: driver}
+
+	if o.stmtCacheSize > 0 {
+		d.cache = newStmtCache(stdDB, o.stmtCacheSize)
+		d.queryer.p = d.cache
+	}
+
+	return &d
 }
 
-func NewDB(driver, uri string) (sql.DB, error) {
+func NewDB(driver, uri string, opts ...Option) (sql.DB, error) {
 	var plainDB, err = stdsql.Open(driver, uri)
 
 	if err != nil {
 		return nil, err
 	}
 
-	return FromStdDB(plainDB, driver), nil
+	return FromStdDB(plainDB, driver, opts...), nil
+}
+
+// StatementCacheStats returns the zero value when the statement cache is
+// not enabled.
+func (d *db) StatementCacheStats() StatementCacheStats {
+	if d.cache == nil {
+		return StatementCacheStats{}
+	}
+
+	return d.cache.Stats()
 }
 
 type tx struct {
@@ -37,6 +74,8 @@ type tx struct {
 
 	q  *queryer
 	tx *stdsql.Tx
+
+	driver string
 }
 
 func (tx *tx) Commit() error {
@@ -65,7 +104,48 @@ func (tx *tx) Rollback() error {
 	return err
 }
 
+// The savepoint statements bypass the statement cache, their names are
+// unique and would only churn it.
+
+func (tx *tx) Savepoint(ctx context.Context, name string) error {
+	return tx.execSavepoint(ctx, "SAVEPOINT", name)
+}
+
+func (tx *tx) ReleaseSavepoint(ctx context.Context, name string) error {
+	return tx.execSavepoint(ctx, "RELEASE SAVEPOINT", name)
+}
+
+func (tx *tx) RollbackTo(ctx context.Context, name string) error {
+	return tx.execSavepoint(ctx, "ROLLBACK TO SAVEPOINT", name)
+}
+
+func (tx *tx) execSavepoint(ctx context.Context, cmd, name string) error {
+	_, err := tx.exec(
+		ctx,
+		&queryer{q: tx.tx},
+		cmd+" "+quoteIdentifier(tx.driver, name),
+	)
+
+	return err
+}
+
+// quoteIdentifier quotes the identifier with backquotes for mysql and with
+// the standard double quotes for the other drivers.
+func quoteIdentifier(driver, name string) string {
+	q := `"`
+
+	if driver == "mysql" {
+		q = "`"
+	}
+
+	return q + strings.ReplaceAll(name, q, q+q) + q
+}
+
 func (tx *tx) Exec(ctx context.Context, qry string, vs ...interface{}) (sql.Result, error) {
+	return tx.exec(ctx, tx.q, qry, vs...)
+}
+
+func (tx *tx) exec(ctx context.Context, q *queryer, qry string, vs ...interface{}) (sql.Result, error) {
 	select {
 	case <-tx.ctx.Done():
 		return nil, tx.ctx.Err()
@@ -74,7 +154,7 @@ func (tx *tx) Exec(ctx context.Context, qry string, vs ...interface{}) (sql.Resu
 	case tx.ch <- struct{}{}:
 	}
 
-	res, err := tx.q.Exec(ctx, qry, vs...)
+	res, err := q.Exec(ctx, qry, vs...)
 	<-tx.ch
 
 	return res, err
@@ -149,6 +229,14 @@ func (tx *tx) Query(ctx context.Context, qry string, vs ...interface{}) (sql.Cur
 
 func (d *db) Driver() string { return d.driver }
 
+func (d *db) Stats() []sql.NodeStats {
+	return []sql.NodeStats{{Stats: d.db.Stats()}}
+}
+
+func (d *db) Ping(ctx context.Context) []sql.NodeHealth {
+	return []sql.NodeHealth{{Err: d.db.PingContext(ctx)}}
+}
+
 func (d *db) BeginTx(ctx context.Context, opts sql.TxOptions) (sql.Tx, error) {
 	t, err := d.db.BeginTx(
 		ctx,
@@ -159,10 +247,22 @@ func (d *db) BeginTx(ctx context.Context, opts sql.TxOptions) (sql.Tx, error) {
 		return nil, err
 	}
 
+	q := queryer{q: t}
+
+	if d.cache != nil {
+		q.p = &txStmtCache{
+			cache: d.cache,
+			tx:    t,
+			stmts: make(map[string]*stdsql.Stmt),
+		}
+	}
+
 	return &tx{
 		ctx: ctx,
 		ch:  make(chan struct{}, 1),
-		q:   &queryer{t},
+		q:   &q,
 		tx:  t,
+
+		driver: d.driver,
 	}, nil
 }
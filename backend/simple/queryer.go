@@ -15,16 +15,53 @@ type stdQueryer interface {
 
 type queryer struct {
 	q stdQueryer
+	p preparer
 }
 
 func (q *queryer) Exec(ctx context.Context, qry string, vs ...interface{}) (sql.Result, error) {
-	return q.q.ExecContext(ctx, qry, sql.StripOptions(vs)...)
+	if q.p == nil || !sql.IsStatementCacheable(vs) {
+		return q.q.ExecContext(ctx, qry, sql.StripOptions(vs)...)
+	}
+
+	stmt, release, err := q.p.prepare(ctx, qry)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer release()
+
+	return stmt.ExecContext(ctx, sql.StripOptions(vs)...)
 }
 
 func (q *queryer) QueryRow(ctx context.Context, qry string, vs ...interface{}) sql.Scanner {
-	return q.q.QueryRowContext(ctx, qry, sql.StripOptions(vs)...)
+	if q.p == nil || !sql.IsStatementCacheable(vs) {
+		return q.q.QueryRowContext(ctx, qry, sql.StripOptions(vs)...)
+	}
+
+	stmt, release, err := q.p.prepare(ctx, qry)
+
+	if err != nil {
+		return errScanner{err}
+	}
+
+	defer release()
+
+	return stmt.QueryRowContext(ctx, sql.StripOptions(vs)...)
 }
 
 func (q *queryer) Query(ctx context.Context, qry string, vs ...interface{}) (sql.Cursor, error) {
-	return q.q.QueryContext(ctx, qry, sql.StripOptions(vs)...)
+	if q.p == nil || !sql.IsStatementCacheable(vs) {
+		return q.q.QueryContext(ctx, qry, sql.StripOptions(vs)...)
+	}
+
+	stmt, release, err := q.p.prepare(ctx, qry)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer release()
+
+	return stmt.QueryContext(ctx, sql.StripOptions(vs)...)
 }
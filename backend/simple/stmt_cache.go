@@ -0,0 +1,166 @@
+package simple
+
+import (
+	"container/list"
+	"context"
+	stdsql "database/sql"
+	"sync"
+
+	"github.com/upfluence/sql"
+)
+
+// StatementCacheStats reports the activity of the prepared statement cache.
+type StatementCacheStats = sql.StatementCacheStats
+
+// StatementCacher is implemented by the DBs caching their prepared
+// statements.
+type StatementCacher = sql.StatementCacher
+
+type preparer interface {
+	prepare(context.Context, string) (*stdsql.Stmt, func(), error)
+}
+
+type stmtEntry struct {
+	query string
+	stmt  *stdsql.Stmt
+
+	refs    int
+	evicted bool
+}
+
+// stmtCache is a LRU of the statements prepared on a *stdsql.DB. The
+// statements are reference counted so an eviction only closes them once the
+// queries using them returned, the rows still opened keep the underlying
+// statement alive on their own.
+type stmtCache struct {
+	db   *stdsql.DB
+	size int
+
+	mu      sync.Mutex
+	ll      *list.List
+	entries map[string]*list.Element
+	stats   StatementCacheStats
+}
+
+func newStmtCache(db *stdsql.DB, size int) *stmtCache {
+	return &stmtCache{
+		db:      db,
+		size:    size,
+		ll:      list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+func (sc *stmtCache) prepare(ctx context.Context, qry string) (*stdsql.Stmt, func(), error) {
+	sc.mu.Lock()
+
+	if e, ok := sc.entries[qry]; ok {
+		sc.stats.Hits++
+		sc.ll.MoveToFront(e)
+
+		se := e.Value.(*stmtEntry)
+		se.refs++
+		sc.mu.Unlock()
+
+		return se.stmt, sc.releaser(se), nil
+	}
+
+	sc.stats.Misses++
+	sc.mu.Unlock()
+
+	stmt, err := sc.db.PrepareContext(ctx, qry)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	if e, ok := sc.entries[qry]; ok {
+		// Another caller prepared the same statement concurrently, ours is
+		// only used for this query.
+
+		se := e.Value.(*stmtEntry)
+		se.refs++
+		sc.ll.MoveToFront(e)
+
+		stmt.Close()
+
+		return se.stmt, sc.releaser(se), nil
+	}
+
+	se := &stmtEntry{query: qry, stmt: stmt, refs: 1}
+	sc.entries[qry] = sc.ll.PushFront(se)
+
+	for sc.ll.Len() > sc.size {
+		sc.evict(sc.ll.Back())
+	}
+
+	return stmt, sc.releaser(se), nil
+}
+
+func (sc *stmtCache) evict(e *list.Element) {
+	se := sc.ll.Remove(e).(*stmtEntry)
+
+	delete(sc.entries, se.query)
+	sc.stats.Evictions++
+	se.evicted = true
+
+	if se.refs == 0 {
+		se.stmt.Close()
+	}
+}
+
+func (sc *stmtCache) releaser(se *stmtEntry) func() {
+	return func() {
+		sc.mu.Lock()
+		defer sc.mu.Unlock()
+
+		se.refs--
+
+		if se.evicted && se.refs == 0 {
+			se.stmt.Close()
+		}
+	}
+}
+
+func (sc *stmtCache) Stats() StatementCacheStats {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	stats := sc.stats
+	stats.Size = sc.ll.Len()
+
+	return stats
+}
+
+// txStmtCache binds the statements of the DB cache to a transaction, they
+// are closed by database/sql once the transaction is done.
+type txStmtCache struct {
+	cache *stmtCache
+	tx    *stdsql.Tx
+
+	stmts map[string]*stdsql.Stmt
+}
+
+func (tc *txStmtCache) prepare(ctx context.Context, qry string) (*stdsql.Stmt, func(), error) {
+	if stmt, ok := tc.stmts[qry]; ok {
+		return stmt, noop, nil
+	}
+
+	stmt, release, err := tc.cache.prepare(ctx, qry)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer release()
+
+	txStmt := tc.tx.StmtContext(ctx, stmt)
+	tc.stmts[qry] = txStmt
+
+	return txStmt, noop, nil
+}
+
+func noop() {}
@@ -0,0 +1,310 @@
+package simple
+
+import (
+	"context"
+	stdsql "database/sql"
+	"database/sql/driver"
+	"io"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/upfluence/sql"
+	"github.com/upfluence/sql/backend/roundrobin"
+	"github.com/upfluence/sql/middleware/sqlcommenter"
+)
+
+type countingDriver struct {
+	mu       sync.Mutex
+	prepares map[string]int
+	closes   map[string]int
+}
+
+func newCountingDriver() *countingDriver {
+	return &countingDriver{
+		prepares: make(map[string]int),
+		closes:   make(map[string]int),
+	}
+}
+
+func (d *countingDriver) Open(string) (driver.Conn, error) {
+	return &countingConn{d: d}, nil
+}
+
+func (d *countingDriver) count(m map[string]int, qry string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	m[qry]++
+}
+
+func (d *countingDriver) openDB(t *testing.T, opts ...Option) sql.DB {
+	stdDB := stdsql.OpenDB(connector{d})
+	stdDB.SetMaxOpenConns(1)
+
+	t.Cleanup(func() { stdDB.Close() })
+
+	return FromStdDB(stdDB, "counting", opts...)
+}
+
+type connector struct {
+	d *countingDriver
+}
+
+func (c connector) Connect(context.Context) (driver.Conn, error) { return c.d.Open("") }
+func (c connector) Driver() driver.Driver                        { return c.d }
+
+type countingConn struct {
+	d *countingDriver
+}
+
+func (c *countingConn) Prepare(qry string) (driver.Stmt, error) {
+	c.d.count(c.d.prepares, qry)
+	return &countingStmt{d: c.d, qry: qry}, nil
+}
+
+func (c *countingConn) Close() error              { return nil }
+func (c *countingConn) Begin() (driver.Tx, error) { return countingTx{}, nil }
+
+type countingTx struct{}
+
+func (countingTx) Commit() error   { return nil }
+func (countingTx) Rollback() error { return nil }
+
+type countingStmt struct {
+	d   *countingDriver
+	qry string
+}
+
+func (s *countingStmt) Close() error {
+	s.d.count(s.d.closes, s.qry)
+	return nil
+}
+
+func (s *countingStmt) NumInput() int { return -1 }
+
+func (s *countingStmt) Exec([]driver.Value) (driver.Result, error) {
+	return driver.RowsAffected(1), nil
+}
+
+func (s *countingStmt) Query([]driver.Value) (driver.Rows, error) {
+	return emptyRows{}, nil
+}
+
+type emptyRows struct{}
+
+func (emptyRows) Columns() []string         { return []string{"foo"} }
+func (emptyRows) Close() error              { return nil }
+func (emptyRows) Next([]driver.Value) error { return io.EOF }
+
+func TestStatementCache(t *testing.T) {
+	var (
+		ctx = context.Background()
+		d   = newCountingDriver()
+		db  = d.openDB(t, WithStatementCache(2))
+	)
+
+	for _, qry := range []string{"q1", "q1", "q2", "q3"} {
+		_, err := db.Exec(ctx, qry, 1)
+		assert.NoError(t, err)
+	}
+
+	cur, err := db.Query(ctx, "q2")
+	assert.NoError(t, err)
+	assert.False(t, cur.Next())
+	assert.NoError(t, cur.Close())
+
+	assert.Equal(
+		t,
+		StatementCacheStats{Hits: 2, Misses: 3, Evictions: 1, Size: 2},
+		db.(StatementCacher).StatementCacheStats(),
+	)
+	assert.Equal(t, map[string]int{"q1": 1, "q2": 1, "q3": 1}, d.prepares)
+	assert.Equal(t, map[string]int{"q1": 1}, d.closes)
+}
+
+func TestStatementCacheEvictionInUse(t *testing.T) {
+	var (
+		ctx = context.Background()
+		d   = newCountingDriver()
+		sdb = d.openDB(t, WithStatementCache(1))
+
+		sc = sdb.(*db).cache
+	)
+
+	stmt, release, err := sc.prepare(ctx, "q1")
+	assert.NoError(t, err)
+
+	_, err = sdb.Exec(ctx, "q2")
+	assert.NoError(t, err)
+
+	assert.Empty(t, d.closes)
+
+	_, err = stmt.ExecContext(ctx)
+	assert.NoError(t, err)
+
+	release()
+
+	assert.Equal(t, map[string]int{"q1": 1}, d.closes)
+}
+
+func TestStatementCacheTx(t *testing.T) {
+	var (
+		ctx = context.Background()
+		d   = newCountingDriver()
+		db  = d.openDB(t, WithStatementCache(4))
+	)
+
+	_, err := db.Exec(ctx, "q1")
+	assert.NoError(t, err)
+
+	err = sql.ExecuteTx(
+		ctx,
+		db,
+		sql.TxOptions{},
+		func(q sql.Queryer) error {
+			for i := 0; i < 2; i++ {
+				if _, err := q.Exec(ctx, "q1"); err != nil {
+					return err
+				}
+			}
+
+			return q.(sql.Savepointer).Savepoint(ctx, "sp_1")
+		},
+	)
+	assert.NoError(t, err)
+
+	assert.Equal(
+		t,
+		StatementCacheStats{Hits: 1, Misses: 1, Size: 1},
+		db.(StatementCacher).StatementCacheStats(),
+	)
+	assert.Equal(t, 1, d.prepares["q1"])
+	assert.Equal(t, 1, d.prepares[`SAVEPOINT "sp_1"`])
+}
+
+func TestSavepointQuoting(t *testing.T) {
+	for _, tt := range []struct {
+		driver string
+		want   []string
+	}{
+		{
+			driver: "postgres",
+			want: []string{
+				`SAVEPOINT "sp ""1"""`,
+				`ROLLBACK TO SAVEPOINT "sp ""1"""`,
+				`RELEASE SAVEPOINT "sp ""1"""`,
+			},
+		},
+		{
+			driver: "mysql",
+			want: []string{
+				"SAVEPOINT `sp \"1\"`",
+				"ROLLBACK TO SAVEPOINT `sp \"1\"`",
+				"RELEASE SAVEPOINT `sp \"1\"`",
+			},
+		},
+	} {
+		t.Run(tt.driver, func(t *testing.T) {
+			var (
+				ctx   = context.Background()
+				d     = newCountingDriver()
+				stdDB = stdsql.OpenDB(connector{d})
+				db    = FromStdDB(stdDB, tt.driver)
+			)
+
+			defer stdDB.Close()
+
+			tx, err := db.BeginTx(ctx, sql.TxOptions{})
+			assert.NoError(t, err)
+
+			sp := tx.(sql.Savepointer)
+
+			assert.NoError(t, sp.Savepoint(ctx, `sp "1"`))
+			assert.NoError(t, sp.RollbackTo(ctx, `sp "1"`))
+			assert.NoError(t, sp.ReleaseSavepoint(ctx, `sp "1"`))
+			assert.NoError(t, tx.Commit())
+
+			for _, stmt := range tt.want {
+				assert.Equal(t, 1, d.prepares[stmt], stmt)
+			}
+		})
+	}
+}
+
+func TestStatementCacheCommented(t *testing.T) {
+	var (
+		ctx = context.Background()
+		d   = newCountingDriver()
+		db  = d.openDB(t, WithStatementCache(2))
+		cdb = sqlcommenter.NewFactory(sqlcommenter.WithCaller()).Wrap(db)
+	)
+
+	for i := 0; i < 2; i++ {
+		_, err := db.Exec(ctx, "q1")
+		assert.NoError(t, err)
+	}
+
+	for i := 0; i < 4; i++ {
+		_, err := cdb.Exec(sql.WithQueryTag(ctx, "i", strconv.Itoa(i)), "q2")
+		assert.NoError(t, err)
+	}
+
+	_, err := db.Exec(ctx, "q1")
+	assert.NoError(t, err)
+
+	assert.Equal(
+		t,
+		StatementCacheStats{Hits: 2, Misses: 1, Size: 1},
+		db.(StatementCacher).StatementCacheStats(),
+	)
+	assert.Equal(t, 1, d.prepares["q1"])
+}
+
+func TestStatementCacheDisabled(t *testing.T) {
+	var (
+		d  = newCountingDriver()
+		db = d.openDB(t)
+	)
+
+	_, err := db.Exec(context.Background(), "q1")
+	assert.NoError(t, err)
+
+	assert.Equal(
+		t,
+		StatementCacheStats{},
+		db.(StatementCacher).StatementCacheStats(),
+	)
+}
+
+func TestStatementCacheRoundRobin(t *testing.T) {
+	var (
+		ctx = context.Background()
+
+		d1 = newCountingDriver()
+		d2 = newCountingDriver()
+
+		db1 = d1.openDB(t, WithStatementCache(2))
+		db2 = d2.openDB(t, WithStatementCache(2))
+
+		db = roundrobin.NewDB(db1, db2)
+	)
+
+	for i := 0; i < 4; i++ {
+		_, err := db.Exec(ctx, "q1")
+		assert.NoError(t, err)
+	}
+
+	for _, sdb := range []sql.DB{db1, db2} {
+		assert.Equal(
+			t,
+			StatementCacheStats{Hits: 1, Misses: 1, Size: 1},
+			sdb.(StatementCacher).StatementCacheStats(),
+		)
+	}
+
+	assert.Equal(t, map[string]int{"q1": 1}, d1.prepares)
+	assert.Equal(t, map[string]int{"q1": 1}, d2.prepares)
+}
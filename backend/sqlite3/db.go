@@ -2,7 +2,6 @@ package sqlite3
 
 import (
 	"context"
-	"regexp"
 	"strconv"
 	"strings"
 
@@ -10,13 +9,10 @@ import (
 	"github.com/upfluence/errors"
 
 	"github.com/upfluence/sql"
+	"github.com/upfluence/sql/sqlparser"
 )
 
-var (
-	argRegexp = regexp.MustCompile(`\$\d+`)
-
-	ErrInvalidArgsNumber = errors.New("invalid arg number")
-)
+var ErrInvalidArgsNumber = errors.New("invalid arg number")
 
 type db struct {
 	*queryer
@@ -47,48 +43,104 @@ type tx struct {
 func (tx *tx) Commit() error   { return wrapErr(tx.tx.Commit()) }
 func (tx *tx) Rollback() error { return wrapErr(tx.tx.Rollback()) }
 
+func (tx *tx) Savepoint(ctx context.Context, name string) error {
+	return tx.execSavepoint(ctx, "SAVEPOINT", name)
+}
+
+func (tx *tx) ReleaseSavepoint(ctx context.Context, name string) error {
+	return tx.execSavepoint(ctx, "RELEASE SAVEPOINT", name)
+}
+
+func (tx *tx) RollbackTo(ctx context.Context, name string) error {
+	return tx.execSavepoint(ctx, "ROLLBACK TO SAVEPOINT", name)
+}
+
+func (tx *tx) execSavepoint(ctx context.Context, cmd, name string) error {
+	_, err := tx.q.Exec(ctx, cmd+" "+quoteIdentifier(name))
+	return wrapErr(err)
+}
+
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
 func (db *db) Driver() string { return db.db.Driver() }
 
+func (db *db) Stats() []sql.NodeStats { return sql.NodeStatsOf("", db.db) }
+
+func (db *db) StatementCacheStats() sql.StatementCacheStats {
+	return sql.StatementCacheStatsOf(db.db)
+}
+
+func (db *db) Ping(ctx context.Context) []sql.NodeHealth {
+	return sql.PingNodes(ctx, "", db.db)
+}
+
 type queryer struct {
 	q sql.Queryer
 }
 
+// deadline bounds the statement by its timeout, go-sqlite3 interrupts the
+// statement once the context is done.
+func deadline(ctx context.Context, vs []interface{}) *sql.StatementDeadline {
+	d, _ := sql.LookupStatementTimeout(ctx, vs)
+
+	return sql.NewStatementDeadline(ctx, d)
+}
+
 func (q *queryer) Exec(ctx context.Context, stmt string, vs ...interface{}) (sql.Result, error) {
+	sd := deadline(ctx, vs)
+	defer sd.Done()
+
 	stmt, vs, err := q.rewrite(stmt, vs)
 
 	if err != nil {
 		return nil, err
 	}
 
-	res, err := q.q.Exec(ctx, stmt, vs...)
+	res, err := q.q.Exec(sd.Context(), stmt, vs...)
 
-	return res, wrapErr(err)
+	return res, sd.WrapErr(err, wrapErr)
 }
 
 func (q *queryer) QueryRow(ctx context.Context, stmt string, vs ...interface{}) sql.Scanner {
+	sd := deadline(ctx, vs)
+
 	stmt, vs, err := q.rewrite(stmt, vs)
 
 	if err != nil {
+		sd.Done()
 		return errScanner{err}
 	}
 
-	return &scanner{sc: q.q.QueryRow(ctx, stmt, vs...)}
+	return &scanner{sc: q.q.QueryRow(sd.Context(), stmt, vs...), sd: sd}
 }
 
 type scanner struct {
 	sc sql.Scanner
+	sd *sql.StatementDeadline
 }
 
 func (sc *scanner) Scan(vs ...interface{}) error {
-	return wrapErr(sc.sc.Scan(vs...))
+	defer sc.sd.Done()
+
+	return sc.sd.WrapErr(sc.sc.Scan(vs...), wrapErr)
 }
 
 type cursor struct {
 	sql.Cursor
+
+	sd *sql.StatementDeadline
 }
 
 func (c *cursor) Scan(vs ...interface{}) error {
-	return wrapErr(c.Cursor.Scan(vs...))
+	return c.sd.WrapErr(c.Cursor.Scan(vs...), wrapErr)
+}
+
+func (c *cursor) Close() error {
+	defer c.sd.Done()
+
+	return c.Cursor.Close()
 }
 
 type errScanner struct {
@@ -98,51 +150,57 @@ type errScanner struct {
 func (es errScanner) Scan(...interface{}) error { return es.error }
 
 func (q *queryer) Query(ctx context.Context, stmt string, vs ...interface{}) (sql.Cursor, error) {
+	sd := deadline(ctx, vs)
+
 	stmt, vs, err := q.rewrite(stmt, vs)
 
 	if err != nil {
+		sd.Done()
 		return nil, err
 	}
 
-	cur, err := q.q.Query(ctx, stmt, vs...)
+	cur, err := q.q.Query(sd.Context(), stmt, vs...)
+
+	if err != nil {
+		sd.Done()
+		return nil, sd.WrapErr(err, wrapErr)
+	}
 
-	return &cursor{Cursor: cur}, wrapErr(err)
+	return &cursor{Cursor: cur, sd: sd}, nil
 }
 
+// rewrite binds the $n placeholders to the numbered ?NNN parameters of
+// SQLite, a placeholder can therefore be referenced multiple times.
 func (q *queryer) rewrite(stmt string, vs []interface{}) (string, []interface{}, error) {
 	var (
-		args = make(map[int]int)
+		seen = make(map[int]struct{})
 
-		i int
+		invalid bool
+
+		ovs = vs
 	)
 
 	vs = sql.StripOptions(vs)
 
-	rstmt := argRegexp.ReplaceAllStringFunc(stmt, func(frag string) string {
-		v, err := strconv.Atoi(strings.TrimPrefix(frag, "$"))
-
-		if err != nil {
-			panic(err)
+	rstmt := sqlparser.RewritePlaceholders(stmt, func(n int) string {
+		if n < 1 || n > len(vs) {
+			invalid = true
 		}
 
-		args[v] = i
-		i++
+		seen[n] = struct{}{}
 
-		return "?"
+		return "?" + strconv.Itoa(n)
 	})
 
-	if len(vs) != len(args) {
+	if invalid || len(seen) != len(vs) {
 		return "", nil, ErrInvalidArgsNumber
 	}
 
 	rvs := make([]interface{}, len(vs))
+	copy(rvs, vs)
 
-	for k, i := range args {
-		if k > len(rvs) {
-			return "", nil, ErrInvalidArgsNumber
-		}
-
-		rvs[i] = vs[k-1]
+	if !sql.IsStatementCacheable(ovs) {
+		rvs = append(rvs, sql.NoStatementCache{})
 	}
 
 	return rstmt, rvs, nil
@@ -164,32 +222,58 @@ func wrapErr(err error) error {
 		return wrapConstraintError(sqlErr)
 	case sqlite3.ErrLocked:
 		return sql.RollbackError{Cause: err, Type: sql.Locked}
+	case sqlite3.ErrCantOpen:
+		return sql.ConnectionError{Cause: err}
 	default:
 		return err
 	}
 }
 
-func parseConstraintName(msg string) string {
-	vs := strings.Split(msg, "constraint failed: ")
+func parseConstraintDetail(msg string) string {
+	vs := strings.SplitN(msg, "constraint failed: ", 2)
 
 	if len(vs) != 2 {
 		return ""
 	}
 
-	vs = strings.Split(vs[1], ".")
+	return vs[1]
+}
+
+// parseConstraintTarget extracts the table and the column from the detail of
+// a constraint error such as "foo.bar", the column is left empty when the
+// constraint spans over multiple columns.
+func parseConstraintTarget(detail string) (string, string) {
+	var table, column string
 
-	if len(vs) != 2 {
-		return ""
+	for i, target := range strings.Split(detail, ", ") {
+		vs := strings.Split(target, ".")
+
+		if len(vs) != 2 {
+			return "", ""
+		}
+
+		if i == 0 {
+			table, column = vs[0], vs[1]
+		} else {
+			column = ""
+		}
 	}
 
-	return vs[1]
+	return table, column
 }
 
 func wrapConstraintError(sqlErr sqlite3.Error) sql.ConstraintError {
-	err := sql.ConstraintError{
-		Cause:      sqlErr,
-		Constraint: parseConstraintName(sqlErr.Error()),
-	}
+	var (
+		detail        = parseConstraintDetail(sqlErr.Error())
+		table, column = parseConstraintTarget(detail)
+
+		err = sql.ConstraintError{
+			Cause:      sqlErr,
+			Constraint: column,
+			Table:      table,
+			Column:     column,
+		}
+	)
 
 	switch sqlErr.ExtendedCode {
 	case sqlite3.ErrConstraintPrimaryKey:
@@ -200,6 +284,9 @@ func wrapConstraintError(sqlErr sqlite3.Error) sql.ConstraintError {
 		err.Type = sql.NotNull
 	case sqlite3.ErrConstraintUnique:
 		err.Type = sql.Unique
+	case sqlite3.ErrConstraintCheck:
+		err.Type = sql.Check
+		err.Constraint = detail
 	}
 
 	return err
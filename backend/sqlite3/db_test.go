@@ -23,22 +23,46 @@ func TestQueryer(t *testing.T) {
 
 		{
 			in:  static.Query{Query: "$1, $2, $3", Args: []interface{}{1, 2, 3}},
-			out: static.Query{Query: "?, ?, ?", Args: []interface{}{1, 2, 3}},
+			out: static.Query{Query: "?1, ?2, ?3", Args: []interface{}{1, 2, 3}},
 		},
 		{
 			in:  static.Query{Query: "$2, $3, $1", Args: []interface{}{1, 2, 3}},
-			out: static.Query{Query: "?, ?, ?", Args: []interface{}{2, 3, 1}},
+			out: static.Query{Query: "?2, ?3, ?1", Args: []interface{}{1, 2, 3}},
 		},
 		{
 			in:  static.Query{Query: "$2, $1, $3", Args: []interface{}{1, 2, 3}},
-			out: static.Query{Query: "?, ?, ?", Args: []interface{}{2, 1, 3}},
+			out: static.Query{Query: "?2, ?1, ?3", Args: []interface{}{1, 2, 3}},
 		},
 		{
 			in: static.Query{
 				Query: "$2, $1, $3",
 				Args:  []interface{}{1, 2, 3, &sql.Returning{Field: "foo"}},
 			},
-			out: static.Query{Query: "?, ?, ?", Args: []interface{}{2, 1, 3}},
+			out: static.Query{Query: "?2, ?1, ?3", Args: []interface{}{1, 2, 3}},
+		},
+		{
+			in:  static.Query{Query: "$1, $2, $1", Args: []interface{}{1, 2}},
+			out: static.Query{Query: "?1, ?2, ?1", Args: []interface{}{1, 2}},
+		},
+		{
+			in: static.Query{
+				Query: "$2, $1 /*trace='a'*/",
+				Args:  []interface{}{1, 2, sql.NoStatementCache{}},
+			},
+			out: static.Query{
+				Query: "?2, ?1 /*trace='a'*/",
+				Args:  []interface{}{1, 2, sql.NoStatementCache{}},
+			},
+		},
+		{
+			in: static.Query{
+				Query: "SELECT '$2', \"$3\" -- $4\n, $1 /* $5 */",
+				Args:  []interface{}{1},
+			},
+			out: static.Query{
+				Query: "SELECT '$2', \"$3\" -- $4\n, ?1 /* $5 */",
+				Args:  []interface{}{1},
+			},
 		},
 		{
 			in:  static.Query{Query: "$2, $1, $3, $4", Args: []interface{}{1, 2, 3}},
@@ -68,3 +92,21 @@ func TestQueryer(t *testing.T) {
 		})
 	}
 }
+
+func TestParseConstraintTarget(t *testing.T) {
+	for _, tt := range []struct {
+		msg, table, column string
+	}{
+		{msg: "UNIQUE constraint failed: foo.bar", table: "foo", column: "bar"},
+		{msg: "UNIQUE constraint failed: foo.bar, foo.baz", table: "foo"},
+		{msg: "FOREIGN KEY constraint failed"},
+		{msg: "CHECK constraint failed: bar > 0"},
+	} {
+		t.Run(tt.msg, func(t *testing.T) {
+			table, column := parseConstraintTarget(parseConstraintDetail(tt.msg))
+
+			assert.Equal(t, tt.table, table)
+			assert.Equal(t, tt.column, column)
+		})
+	}
+}
@@ -47,6 +47,9 @@ type DB struct {
 
 	Tx    sql.Tx
 	TxErr error
+
+	DBStats sql.DBStats
+	PingErr error
 }
 
 type Tx struct {
@@ -60,6 +63,14 @@ func (tx *Tx) Rollback() error { return tx.RollbackErr }
 
 func (db *DB) Driver() string { return "sqltest" }
 
+func (db *DB) Stats() []sql.NodeStats {
+	return []sql.NodeStats{{Stats: db.DBStats}}
+}
+
+func (db *DB) Ping(context.Context) []sql.NodeHealth {
+	return []sql.NodeHealth{{Err: db.PingErr}}
+}
+
 func (db *DB) BeginTx(context.Context, sql.TxOptions) (sql.Tx, error) {
 	return db.Tx, db.TxErr
 }
@@ -0,0 +1,69 @@
+package sql
+
+import (
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff returns the delay to wait before the given retry attempt, attempts
+// are numbered from 0.
+type Backoff interface {
+	Backoff(int) time.Duration
+}
+
+type BackoffFunc func(int) time.Duration
+
+func (fn BackoffFunc) Backoff(i int) time.Duration { return fn(i) }
+
+const maxBackoff = time.Duration(math.MaxInt64)
+
+var (
+	NoBackoff Backoff = BackoffFunc(func(int) time.Duration { return 0 })
+
+	DefaultExponentialBackoff = ExponentialBackoff{
+		Initial:    10 * time.Millisecond,
+		Max:        time.Second,
+		Multiplier: 2,
+		Jitter:     0.5,
+	}
+)
+
+// ExponentialBackoff grows the delay by Multiplier at each attempt, starting
+// at Initial and capped to Max. Jitter is the fraction [0, 1] of the delay
+// randomly removed to spread concurrent retries.
+type ExponentialBackoff struct {
+	Initial    time.Duration
+	Max        time.Duration
+	Multiplier float64
+	Jitter     float64
+}
+
+func (eb ExponentialBackoff) Backoff(i int) time.Duration {
+	m := eb.Multiplier
+
+	if m < 1 {
+		m = 1
+	}
+
+	d := float64(eb.Initial) * math.Pow(m, float64(i))
+
+	if eb.Max > 0 && d > float64(eb.Max) {
+		d = float64(eb.Max)
+	}
+
+	// Without Max the delay grows past what a time.Duration holds.
+	if d > float64(maxBackoff) {
+		d = float64(maxBackoff)
+	}
+
+	if j := math.Min(math.Max(eb.Jitter, 0), 1); j > 0 {
+		d -= d * j * rand.Float64()
+	}
+
+	if d >= float64(maxBackoff) {
+		return maxBackoff
+	}
+
+	return time.Duration(d)
+}
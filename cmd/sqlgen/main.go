@@ -0,0 +1,78 @@
+// Command sqlgen generates Go structs, sqlbuilder markers and repositories
+// from the up migrations of a directory.
+//
+//	sqlgen -migrations ./migrations -package models -out models/models.go
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/upfluence/errors"
+	"github.com/upfluence/log"
+
+	"github.com/upfluence/sql/x/migration"
+	"github.com/upfluence/sql/x/sqlgen"
+)
+
+var (
+	migrations = flag.String("migrations", "", "directory holding the migration files")
+	driver     = flag.String("driver", "postgres", "driver picking the migration files")
+	pkg        = flag.String("package", "models", "package of the generated file")
+	out        = flag.String("out", "", "path of the generated file, stdout when empty")
+	tables     = flag.String("tables", "", "comma separated list of the tables to generate, all when empty")
+	defaults   = flag.Bool("database-defaults", false, "let the database fill the columns having a default value on insert")
+)
+
+func main() {
+	flag.Parse()
+
+	if err := run(context.Background()); err != nil {
+		fmt.Fprintf(os.Stderr, "sqlgen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context) error {
+	if *migrations == "" {
+		return errors.New("-migrations is required")
+	}
+
+	src, err := migration.NewFSSource(os.DirFS(*migrations), log.NewLogger())
+
+	if err != nil {
+		return err
+	}
+
+	s, err := sqlgen.LoadSchema(ctx, src, migration.FetchDriver(*driver))
+
+	if err != nil {
+		return err
+	}
+
+	opts := []sqlgen.Option{sqlgen.WithPackage(*pkg)}
+
+	if *tables != "" {
+		opts = append(opts, sqlgen.WithTables(strings.Split(*tables, ",")...))
+	}
+
+	if *defaults {
+		opts = append(opts, sqlgen.WithDatabaseDefaults())
+	}
+
+	buf, err := sqlgen.Generate(s, opts...)
+
+	if err != nil {
+		return err
+	}
+
+	if *out == "" {
+		_, err = os.Stdout.Write(buf)
+		return err
+	}
+
+	return os.WriteFile(*out, buf, 0644)
+}
@@ -0,0 +1,143 @@
+package sql
+
+import (
+	"context"
+	"time"
+)
+
+type (
+	consistencyKey      struct{}
+	statementTimeoutKey struct{}
+	queryTagsKey        struct{}
+)
+
+// WithConsistency sets the consistency of every query executed with the
+// returned context, it is an alternative to passing a Consistency value as
+// query argument. A StronglyConsistent argument still prevails.
+func WithConsistency(ctx context.Context, c Consistency) context.Context {
+	return context.WithValue(ctx, consistencyKey{}, c)
+}
+
+func ConsistencyFromContext(ctx context.Context) (Consistency, bool) {
+	c, ok := ctx.Value(consistencyKey{}).(Consistency)
+	return c, ok
+}
+
+// IsStronglyConsistent reports whether the query must be served by the
+// master either because of its arguments or its context.
+func IsStronglyConsistent(ctx context.Context, vs []interface{}) bool {
+	if c, ok := ConsistencyFromContext(ctx); ok && c == StronglyConsistent {
+		return true
+	}
+
+	for _, v := range vs {
+		if c, ok := v.(Consistency); ok && c == StronglyConsistent {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WithStatementTimeout bounds the duration of every statement executed with
+// the returned context.
+func WithStatementTimeout(ctx context.Context, d time.Duration) context.Context {
+	return context.WithValue(ctx, statementTimeoutKey{}, d)
+}
+
+func StatementTimeoutFromContext(ctx context.Context) (time.Duration, bool) {
+	d, ok := ctx.Value(statementTimeoutKey{}).(time.Duration)
+	return d, ok && d > 0
+}
+
+type QueryTag struct {
+	Key   string
+	Value string
+}
+
+// WithQueryTag attaches a tag to the queries executed with the returned
+// context, tagging twice the same key overrides its value.
+func WithQueryTag(ctx context.Context, k, v string) context.Context {
+	var (
+		tags  = QueryTags(ctx)
+		ntags = make([]QueryTag, 0, len(tags)+1)
+		found bool
+	)
+
+	for _, t := range tags {
+		if t.Key == k {
+			t.Value = v
+			found = true
+		}
+
+		ntags = append(ntags, t)
+	}
+
+	if !found {
+		ntags = append(ntags, QueryTag{Key: k, Value: v})
+	}
+
+	return context.WithValue(ctx, queryTagsKey{}, ntags)
+}
+
+// QueryTags returns the tags attached to the context in insertion order.
+func QueryTags(ctx context.Context) []QueryTag {
+	tags, _ := ctx.Value(queryTagsKey{}).([]QueryTag)
+	return tags
+}
+
+// LookupStatementTimeout returns the timeout of the statement either given
+// as a StatementTimeout argument or carried by the context.
+func LookupStatementTimeout(ctx context.Context, vs []interface{}) (time.Duration, bool) {
+	for _, v := range vs {
+		if d, ok := v.(StatementTimeout); ok && d > 0 {
+			return time.Duration(d), true
+		}
+	}
+
+	return StatementTimeoutFromContext(ctx)
+}
+
+// StatementDeadline bounds the execution of a single statement.
+type StatementDeadline struct {
+	timeout time.Duration
+
+	parent context.Context
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// NewStatementDeadline derives a context expiring after d, a zero duration
+// leaves the context untouched.
+func NewStatementDeadline(ctx context.Context, d time.Duration) *StatementDeadline {
+	sd := StatementDeadline{
+		timeout: d,
+		parent:  ctx,
+		ctx:     ctx,
+		cancel:  func() {},
+	}
+
+	if d > 0 {
+		sd.ctx, sd.cancel = context.WithTimeout(ctx, d)
+	}
+
+	return &sd
+}
+
+func (sd *StatementDeadline) Context() context.Context { return sd.ctx }
+
+// Done releases the resources of the deadline, it must be called once the
+// statement is over, ie. after the Scan or the Close of the Cursor.
+func (sd *StatementDeadline) Done() { sd.cancel() }
+
+// WrapErr returns a StatementTimeoutError when err was caused by the
+// expiration of the deadline, otherwise err is handed to fallback. A
+// cancellation of the parent context is not considered as a timeout.
+func (sd *StatementDeadline) WrapErr(err error, fallback func(error) error) error {
+	if err != nil && sd.timeout > 0 && sd.parent.Err() == nil &&
+		sd.ctx.Err() == context.DeadlineExceeded {
+		return StatementTimeoutError{Timeout: sd.timeout, Cause: err}
+	}
+
+	return fallback(err)
+}
@@ -0,0 +1,87 @@
+package sql_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/upfluence/sql"
+)
+
+func TestWithQueryTag(t *testing.T) {
+	ctx := sql.WithQueryTag(context.Background(), "foo", "1")
+	ctx = sql.WithQueryTag(ctx, "bar", "2")
+
+	octx := sql.WithQueryTag(ctx, "foo", "3")
+
+	assert.Equal(
+		t,
+		[]sql.QueryTag{{Key: "foo", Value: "1"}, {Key: "bar", Value: "2"}},
+		sql.QueryTags(ctx),
+	)
+	assert.Equal(
+		t,
+		[]sql.QueryTag{{Key: "foo", Value: "3"}, {Key: "bar", Value: "2"}},
+		sql.QueryTags(octx),
+	)
+}
+
+func TestIsStronglyConsistent(t *testing.T) {
+	ctx := context.Background()
+
+	assert.False(t, sql.IsStronglyConsistent(ctx, []interface{}{1}))
+	assert.True(
+		t,
+		sql.IsStronglyConsistent(ctx, []interface{}{sql.StronglyConsistent}),
+	)
+	assert.True(
+		t,
+		sql.IsStronglyConsistent(
+			sql.WithConsistency(ctx, sql.StronglyConsistent),
+			nil,
+		),
+	)
+}
+
+func TestStatementDeadline(t *testing.T) {
+	ctx := context.Background()
+
+	d, ok := sql.LookupStatementTimeout(
+		sql.WithStatementTimeout(ctx, time.Second),
+		[]interface{}{1, sql.StatementTimeout(time.Minute)},
+	)
+
+	assert.True(t, ok)
+	assert.Equal(t, time.Minute, d)
+
+	sd := sql.NewStatementDeadline(ctx, 0)
+	sd.Done()
+
+	_, ok = sd.Context().Deadline()
+	assert.False(t, ok)
+	assert.Equal(t, context.Canceled, sd.WrapErr(context.Canceled, identity))
+
+	sd = sql.NewStatementDeadline(ctx, time.Millisecond)
+	defer sd.Done()
+
+	<-sd.Context().Done()
+
+	err := sd.WrapErr(errors.New("interrupted"), identity)
+
+	assert.Equal(t, sql.TimeoutErrorClass, sql.ClassifyError(err))
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.False(t, errors.Is(err, context.Canceled))
+
+	cctx, cancel := context.WithCancel(ctx)
+	sd = sql.NewStatementDeadline(cctx, time.Minute)
+	defer sd.Done()
+
+	cancel()
+
+	assert.Equal(t, context.Canceled, sd.WrapErr(context.Canceled, identity))
+}
+
+func identity(err error) error { return err }
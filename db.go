@@ -3,6 +3,7 @@ package sql
 import (
 	"context"
 	"database/sql"
+	"time"
 )
 
 type (
@@ -59,6 +60,31 @@ const (
 	StronglyConsistent
 )
 
+// StatementTimeout bounds the duration of the statement it is given to as
+// argument, it prevails over the timeout carried by the context.
+type StatementTimeout time.Duration
+
+func (StatementTimeout) IsSQLOption() {}
+
+// NoStatementCache keeps the statement it is given to as argument out of the
+// prepared statement caches, its text being unique such as the one of a
+// statement holding per query tags.
+type NoStatementCache struct{}
+
+func (NoStatementCache) IsSQLOption() {}
+
+// IsStatementCacheable reports whether the statement can be kept in the
+// prepared statement caches.
+func IsStatementCacheable(vs []interface{}) bool {
+	for _, v := range vs {
+		if _, ok := v.(NoStatementCache); ok {
+			return false
+		}
+	}
+
+	return true
+}
+
 func StripOptions(vs []interface{}) []interface{} {
 	var res []interface{}
 
@@ -1,5 +1,12 @@
 package sql
 
+import (
+	"context"
+	"time"
+
+	"github.com/upfluence/errors"
+)
+
 type ConstraintType int
 
 const (
@@ -7,12 +14,19 @@ const (
 	ForeignKey
 	NotNull
 	Unique
+	Check
+	Exclusion
 )
 
+// ConstraintError is returned when a statement violates a constraint. Table
+// and Column are filled when the backend reports them.
 type ConstraintError struct {
 	Type       ConstraintType
 	Constraint string
 
+	Table  string
+	Column string
+
 	Cause error
 }
 
@@ -25,6 +39,7 @@ type RollbackType int
 const (
 	SerializationFailure RollbackType = iota + 1
 	Locked
+	Deadlock
 )
 
 type RollbackError struct {
@@ -35,3 +50,80 @@ type RollbackError struct {
 func (re RollbackError) Error() string {
 	return re.Cause.Error()
 }
+
+// ConnectionError is returned when the connection to the database is lost
+// or shut down by the server, the statement may or may not have been
+// executed.
+type ConnectionError struct {
+	Cause error
+}
+
+func (ce ConnectionError) Error() string {
+	return ce.Cause.Error()
+}
+
+func (ce ConnectionError) Unwrap() error { return ce.Cause }
+
+// StatementTimeoutError is returned when a statement is aborted because it
+// ran longer than its statement timeout, as opposed to a cancellation
+// requested by the caller. Timeout is zero when the backend can not tell
+// which timeout expired.
+type StatementTimeoutError struct {
+	Timeout time.Duration
+	Cause   error
+}
+
+func (ste StatementTimeoutError) Error() string {
+	return ste.Cause.Error()
+}
+
+func (ste StatementTimeoutError) Is(target error) bool {
+	return target == context.DeadlineExceeded
+}
+
+func (ste StatementTimeoutError) Unwrap() error { return ste.Cause }
+
+type ErrorClass string
+
+const (
+	NoErrorClass         ErrorClass = ""
+	NoRowsErrorClass     ErrorClass = "no_rows"
+	ConstraintErrorClass ErrorClass = "constraint"
+	RollbackErrorClass   ErrorClass = "rollback"
+	ConnectionErrorClass ErrorClass = "connection"
+	TimeoutErrorClass    ErrorClass = "timeout"
+	CanceledErrorClass   ErrorClass = "canceled"
+	UnknownErrorClass    ErrorClass = "unknown"
+)
+
+// ClassifyError buckets an error returned by a DB into a low cardinality
+// class fit for metrics and tracing.
+func ClassifyError(err error) ErrorClass {
+	if err == nil {
+		return NoErrorClass
+	}
+
+	var (
+		ce  ConstraintError
+		re  RollbackError
+		ste StatementTimeoutError
+		cne ConnectionError
+	)
+
+	switch {
+	case errors.Is(err, ErrNoRows):
+		return NoRowsErrorClass
+	case errors.As(err, &ce):
+		return ConstraintErrorClass
+	case errors.As(err, &re):
+		return RollbackErrorClass
+	case errors.As(err, &cne):
+		return ConnectionErrorClass
+	case errors.As(err, &ste):
+		return TimeoutErrorClass
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return CanceledErrorClass
+	}
+
+	return UnknownErrorClass
+}
@@ -20,7 +20,7 @@ func TestCanceling(t *testing.T) {
 		var stmt string
 
 		switch d := db.Driver(); d {
-		case "postgres":
+		case "postgres", "pgx":
 			stmt = "pg_sleep(1)"
 		default:
 			t.Skipf("driver not handled: %q", d)
@@ -72,6 +72,7 @@ func TestConstraintPrimaryKeyError(t *testing.T) {
 			map[string]string{
 				"sqlite3":  "fiz",
 				"postgres": "foo_pkey",
+				"pgx":      "foo_pkey",
 			}[db.Driver()],
 			cerr.Constraint,
 		)
@@ -102,6 +103,8 @@ func TestConstraintNotNullError(t *testing.T) {
 		assert.True(t, ok)
 		assert.Equal(t, sql.NotNull, cerr.Type)
 		assert.Equal(t, "fiz", cerr.Constraint)
+		assert.Equal(t, "foo", cerr.Table)
+		assert.Equal(t, "fiz", cerr.Column)
 
 		_, err = db.Exec(ctx, "INSERT INTO foo(fiz) VALUES ($1)", "bar")
 		assert.Nil(t, err)
@@ -131,11 +134,13 @@ func TestConstraintUniqueError(t *testing.T) {
 
 		assert.True(t, ok)
 		assert.Equal(t, sql.Unique, cerr.Type)
+		assert.Equal(t, "foo", cerr.Table)
 		assert.Equal(
 			t,
 			map[string]string{
 				"sqlite3":  "fiz",
 				"postgres": "foo_fiz_key",
+				"pgx":      "foo_fiz_key",
 			}[db.Driver()],
 			cerr.Constraint,
 		)
@@ -144,3 +149,30 @@ func TestConstraintUniqueError(t *testing.T) {
 		assert.Nil(t, err)
 	})
 }
+
+func TestConstraintCheckError(t *testing.T) {
+	sqltest.NewTestCase(
+		sqltest.WithMigratorFunc(func(db sql.DB) migration.Migrator {
+			return migration.NewMigrator(
+				db,
+				staticSource{
+					up:   "CREATE TABLE foo(fiz INTEGER CONSTRAINT fiz_positive CHECK (fiz > 0))",
+					down: "DROP TABLE foo",
+				},
+			)
+		}),
+	).Run(t, func(t *testing.T, db sql.DB) {
+		ctx := context.Background()
+
+		_, err := db.Exec(ctx, "INSERT INTO foo(fiz) VALUES ($1)", -1)
+
+		cerr, ok := err.(sql.ConstraintError)
+
+		assert.True(t, ok)
+		assert.Equal(t, sql.Check, cerr.Type)
+		assert.Equal(t, "fiz_positive", cerr.Constraint)
+
+		_, err = db.Exec(ctx, "INSERT INTO foo(fiz) VALUES ($1)", 1)
+		assert.Nil(t, err)
+	})
+}
@@ -0,0 +1,30 @@
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/upfluence/sql"
+	"github.com/upfluence/sql/sqltest"
+)
+
+func TestRepeatedPlaceholder(t *testing.T) {
+	sqltest.NewTestCase().Run(t, func(t *testing.T, db sql.DB) {
+		var (
+			n int
+			s string
+		)
+
+		err := db.QueryRow(
+			context.Background(),
+			"SELECT CAST($1 AS INTEGER) + CAST($1 AS INTEGER), '$2' -- $3",
+			21,
+		).Scan(&n, &s)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 42, n)
+		assert.Equal(t, "$2", s)
+	})
+}
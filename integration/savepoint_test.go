@@ -0,0 +1,64 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/upfluence/sql"
+	"github.com/upfluence/sql/sqltest"
+	"github.com/upfluence/sql/x/migration"
+)
+
+func TestExecuteNestedTx(t *testing.T) {
+	sqltest.NewTestCase(
+		sqltest.WithMigratorFunc(func(db sql.DB) migration.Migrator {
+			return migration.NewMigrator(
+				db,
+				staticSource{
+					up:   "CREATE TABLE foo(fiz TEXT)",
+					down: "DROP TABLE foo",
+				},
+			)
+		}),
+	).Run(t, func(t *testing.T, db sql.DB) {
+		var (
+			ctx    = context.Background()
+			errFoo = errors.New("foo")
+		)
+
+		insert := func(v string, ret error) sql.QueryerFunc {
+			return func(q sql.Queryer) error {
+				if _, err := q.Exec(ctx, "INSERT INTO foo(fiz) VALUES ($1)", v); err != nil {
+					return err
+				}
+
+				return ret
+			}
+		}
+
+		err := sql.ExecuteTx(
+			ctx,
+			db,
+			sql.TxOptions{},
+			func(q sql.Queryer) error {
+				if err := sql.ExecuteNestedTx(ctx, q, sql.TxOptions{}, insert("bar", nil)); err != nil {
+					return err
+				}
+
+				assert.Equal(
+					t,
+					errFoo,
+					sql.ExecuteNestedTx(ctx, q, sql.TxOptions{}, insert("buz", errFoo)),
+				)
+
+				return sql.ExecuteNestedTx(ctx, q, sql.TxOptions{}, insert("biz", sql.ErrRollback))
+			},
+		)
+
+		assert.NoError(t, err)
+		assertFoo(t, db, []string{"bar"})
+	})
+}
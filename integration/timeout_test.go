@@ -0,0 +1,45 @@
+package integration
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/upfluence/sql"
+	"github.com/upfluence/sql/sqltest"
+)
+
+const infiniteQuery = `
+WITH RECURSIVE t(n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM t)
+SELECT COUNT(*) FROM t
+`
+
+func TestStatementTimeout(t *testing.T) {
+	sqltest.NewTestCase().Run(t, func(t *testing.T, db sql.DB) {
+		var (
+			ctx = context.Background()
+			n   int
+		)
+
+		err := db.QueryRow(
+			ctx,
+			infiniteQuery,
+			sql.StatementTimeout(50*time.Millisecond),
+		).Scan(&n)
+
+		var ste sql.StatementTimeoutError
+
+		assert.True(t, errors.As(err, &ste))
+		assert.False(t, errors.Is(err, context.Canceled))
+
+		err = db.QueryRow(
+			sql.WithStatementTimeout(ctx, 50*time.Millisecond),
+			infiniteQuery,
+		).Scan(&n)
+
+		assert.True(t, errors.As(err, &ste))
+	})
+}
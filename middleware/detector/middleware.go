@@ -0,0 +1,325 @@
+package detector
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/upfluence/log"
+	"github.com/upfluence/log/record"
+
+	"github.com/upfluence/sql"
+	"github.com/upfluence/sql/sqlparser"
+)
+
+var defaultFactory = factory{
+	slowThreshold:   time.Second,
+	repeatThreshold: 10,
+	normalize:       sqlparser.Normalize,
+}
+
+type Option func(*factory)
+
+// WithSlowThreshold sets the duration above which a query is reported as
+// slow, a zero value disables the detection.
+func WithSlowThreshold(d time.Duration) Option {
+	return func(f *factory) { f.slowThreshold = d }
+}
+
+// WithRepeatThreshold sets the number of executions of the same statement
+// within a scope from which it is reported, a zero value disables the
+// detection.
+func WithRepeatThreshold(n int) Option {
+	return func(f *factory) { f.repeatThreshold = n }
+}
+
+func WithSink(s Sink) Option {
+	return func(f *factory) { f.sink = s }
+}
+
+// WithNormalizer overrides the function computing the statement fingerprint
+// used to group the executions.
+func WithNormalizer(fn func(string) string) Option {
+	return func(f *factory) { f.normalize = fn }
+}
+
+// Factory builds a middleware reporting slow queries and statements
+// executed many times within a request scope or a transaction (N+1).
+type Factory interface {
+	sql.MiddlewareFactory
+
+	// WithScope opens a request scope in which the repeated statements are
+	// counted, they are reported when the returned function is called.
+	WithScope(context.Context) (context.Context, func())
+}
+
+type factory struct {
+	slowThreshold   time.Duration
+	repeatThreshold int
+	normalize       func(string) string
+
+	sink Sink
+}
+
+func NewFactory(opts ...Option) Factory {
+	f := defaultFactory
+
+	for _, opt := range opts {
+		opt(&f)
+	}
+
+	if f.sink == nil {
+		f.sink = NewLogSink(log.NewLogger(), record.Warning)
+	}
+
+	return &f
+}
+
+type scopeKey struct{}
+
+type queryStat struct {
+	count int
+	total time.Duration
+}
+
+type scope struct {
+	kind ScopeKind
+
+	mu    sync.Mutex
+	keys  []string
+	stats map[string]*queryStat
+}
+
+func newScope(k ScopeKind) *scope {
+	return &scope{kind: k, stats: make(map[string]*queryStat)}
+}
+
+func (s *scope) record(fp string, d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, ok := s.stats[fp]
+
+	if !ok {
+		st = &queryStat{}
+		s.stats[fp] = st
+		s.keys = append(s.keys, fp)
+	}
+
+	st.count++
+	st.total += d
+}
+
+func (f *factory) flush(ctx context.Context, s *scope) {
+	if f.repeatThreshold <= 0 {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, k := range s.keys {
+		if st := s.stats[k]; st.count >= f.repeatThreshold {
+			f.sink.ReportRepeatedQuery(
+				ctx,
+				RepeatedQuery{
+					Fingerprint: k,
+					Scope:       s.kind,
+					Count:       st.count,
+					Total:       st.total,
+				},
+			)
+		}
+	}
+
+	s.keys = nil
+	s.stats = make(map[string]*queryStat)
+}
+
+func (f *factory) WithScope(ctx context.Context) (context.Context, func()) {
+	s := newScope(RequestScope)
+
+	return context.WithValue(ctx, scopeKey{}, s), func() { f.flush(ctx, s) }
+}
+
+func (f *factory) Wrap(d sql.DB) sql.DB {
+	return &db{queryer: &queryer{q: d, f: f}, db: d}
+}
+
+type db struct {
+	*queryer
+
+	db sql.DB
+}
+
+func (d *db) Driver() string { return d.db.Driver() }
+
+func (d *db) Stats() []sql.NodeStats { return sql.NodeStatsOf("", d.db) }
+
+func (d *db) StatementCacheStats() sql.StatementCacheStats {
+	return sql.StatementCacheStatsOf(d.db)
+}
+
+func (d *db) Ping(ctx context.Context) []sql.NodeHealth {
+	return sql.PingNodes(ctx, "", d.db)
+}
+
+func (d *db) BeginTx(ctx context.Context, opts sql.TxOptions) (sql.Tx, error) {
+	t, err := d.db.BeginTx(ctx, opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	s := newScope(TransactionScope)
+
+	return &tx{
+		queryer: &queryer{q: t, f: d.f, txScope: s},
+		tx:      t,
+		ctx:     ctx,
+		s:       s,
+	}, nil
+}
+
+type tx struct {
+	*queryer
+
+	tx  sql.Tx
+	ctx context.Context
+	s   *scope
+}
+
+func (t *tx) Commit() error {
+	defer t.f.flush(t.ctx, t.s)
+
+	return t.tx.Commit()
+}
+
+func (t *tx) Rollback() error {
+	defer t.f.flush(t.ctx, t.s)
+
+	return t.tx.Rollback()
+}
+
+func (t *tx) Savepoint(ctx context.Context, name string) error {
+	sp, ok := t.tx.(sql.Savepointer)
+
+	if !ok {
+		return sql.ErrSavepointNotSupported
+	}
+
+	return sp.Savepoint(ctx, name)
+}
+
+func (t *tx) ReleaseSavepoint(ctx context.Context, name string) error {
+	sp, ok := t.tx.(sql.Savepointer)
+
+	if !ok {
+		return sql.ErrSavepointNotSupported
+	}
+
+	return sp.ReleaseSavepoint(ctx, name)
+}
+
+func (t *tx) RollbackTo(ctx context.Context, name string) error {
+	sp, ok := t.tx.(sql.Savepointer)
+
+	if !ok {
+		return sql.ErrSavepointNotSupported
+	}
+
+	return sp.RollbackTo(ctx, name)
+}
+
+type queryer struct {
+	q sql.Queryer
+	f *factory
+
+	txScope *scope
+}
+
+func (q *queryer) observe(ctx context.Context, stmt string, t0 time.Time) {
+	var (
+		d  = time.Since(t0)
+		fp = q.f.normalize(stmt)
+	)
+
+	if q.f.slowThreshold > 0 && d >= q.f.slowThreshold {
+		q.f.sink.ReportSlowQuery(
+			ctx,
+			SlowQuery{Statement: stmt, Fingerprint: fp, Duration: d},
+		)
+	}
+
+	if q.txScope != nil {
+		q.txScope.record(fp, d)
+	}
+
+	if s, ok := ctx.Value(scopeKey{}).(*scope); ok {
+		s.record(fp, d)
+	}
+}
+
+func (q *queryer) Exec(ctx context.Context, stmt string, vs ...interface{}) (sql.Result, error) {
+	var t0 = time.Now()
+
+	defer q.observe(ctx, stmt, t0)
+
+	return q.q.Exec(ctx, stmt, vs...)
+}
+
+func (q *queryer) QueryRow(ctx context.Context, stmt string, vs ...interface{}) sql.Scanner {
+	var t0 = time.Now()
+
+	return &scanner{
+		sc:   q.q.QueryRow(ctx, stmt, vs...),
+		q:    q,
+		ctx:  ctx,
+		stmt: stmt,
+		t0:   t0,
+	}
+}
+
+func (q *queryer) Query(ctx context.Context, stmt string, vs ...interface{}) (sql.Cursor, error) {
+	var t0 = time.Now()
+
+	cur, err := q.q.Query(ctx, stmt, vs...)
+
+	if err != nil {
+		q.observe(ctx, stmt, t0)
+		return nil, err
+	}
+
+	return &cursor{Cursor: cur, q: q, ctx: ctx, stmt: stmt, t0: t0}, nil
+}
+
+type scanner struct {
+	sc  sql.Scanner
+	q   *queryer
+	ctx context.Context
+
+	stmt string
+	t0   time.Time
+}
+
+func (sc *scanner) Scan(vs ...interface{}) error {
+	defer sc.q.observe(sc.ctx, sc.stmt, sc.t0)
+
+	return sc.sc.Scan(vs...)
+}
+
+type cursor struct {
+	sql.Cursor
+
+	q   *queryer
+	ctx context.Context
+
+	stmt string
+	t0   time.Time
+}
+
+func (c *cursor) Close() error {
+	defer c.q.observe(c.ctx, c.stmt, c.t0)
+
+	return c.Cursor.Close()
+}
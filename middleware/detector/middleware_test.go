@@ -0,0 +1,104 @@
+package detector
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/upfluence/sql"
+	"github.com/upfluence/sql/backend/static"
+)
+
+type mockSink struct {
+	slow     []SlowQuery
+	repeated []RepeatedQuery
+}
+
+func (ms *mockSink) ReportSlowQuery(_ context.Context, sq SlowQuery) {
+	ms.slow = append(ms.slow, sq)
+}
+
+func (ms *mockSink) ReportRepeatedQuery(_ context.Context, rq RepeatedQuery) {
+	rq.Total = 0
+	ms.repeated = append(ms.repeated, rq)
+}
+
+type slowScanner struct{}
+
+func (slowScanner) Scan(...interface{}) error {
+	time.Sleep(5 * time.Millisecond)
+	return nil
+}
+
+func TestSlowQuery(t *testing.T) {
+	var (
+		ms mockSink
+		db = NewFactory(WithSink(&ms), WithSlowThreshold(time.Millisecond)).Wrap(
+			&static.DB{Queryer: static.Queryer{QueryRowScanner: slowScanner{}}},
+		)
+	)
+
+	sc := db.QueryRow(context.Background(), "SELECT  foo")
+	assert.Len(t, ms.slow, 0)
+
+	assert.NoError(t, sc.Scan())
+	assert.Len(t, ms.slow, 1)
+	assert.Equal(t, "SELECT  foo", ms.slow[0].Statement)
+	assert.Equal(t, "SELECT foo", ms.slow[0].Fingerprint)
+}
+
+func TestRepeatedQuery(t *testing.T) {
+	var (
+		ms mockSink
+		f  = NewFactory(WithSink(&ms), WithRepeatThreshold(3))
+		db = f.Wrap(
+			&static.DB{
+				Queryer: static.Queryer{ExecResult: sql.StaticResult(1)},
+				Tx: &static.Tx{
+					Queryer: static.Queryer{ExecResult: sql.StaticResult(1)},
+				},
+			},
+		)
+	)
+
+	ctx, done := f.WithScope(context.Background())
+
+	tx, err := db.BeginTx(ctx, sql.TxOptions{})
+	assert.NoError(t, err)
+
+	for i := 0; i < 3; i++ {
+		tx.Exec(ctx, "UPDATE foo SET bar = ?", i)
+	}
+
+	tx.Exec(ctx, "DELETE FROM foo")
+
+	assert.NoError(t, tx.Commit())
+	assert.Equal(
+		t,
+		[]RepeatedQuery{
+			{
+				Fingerprint: "UPDATE foo SET bar = ?",
+				Scope:       TransactionScope,
+				Count:       3,
+			},
+		},
+		ms.repeated,
+	)
+
+	db.Exec(ctx, "UPDATE foo SET bar = ?", 4)
+	db.Exec(ctx, "DELETE FROM foo")
+	done()
+
+	assert.Equal(
+		t,
+		RepeatedQuery{
+			Fingerprint: "UPDATE foo SET bar = ?",
+			Scope:       RequestScope,
+			Count:       4,
+		},
+		ms.repeated[1],
+	)
+	assert.Len(t, ms.repeated, 2)
+}
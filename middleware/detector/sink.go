@@ -0,0 +1,58 @@
+package detector
+
+import (
+	"context"
+	"time"
+
+	"github.com/upfluence/log"
+	"github.com/upfluence/log/record"
+)
+
+type ScopeKind string
+
+const (
+	RequestScope     ScopeKind = "request"
+	TransactionScope ScopeKind = "transaction"
+)
+
+type SlowQuery struct {
+	Statement   string
+	Fingerprint string
+	Duration    time.Duration
+}
+
+type RepeatedQuery struct {
+	Fingerprint string
+	Scope       ScopeKind
+	Count       int
+	Total       time.Duration
+}
+
+type Sink interface {
+	ReportSlowQuery(context.Context, SlowQuery)
+	ReportRepeatedQuery(context.Context, RepeatedQuery)
+}
+
+type logSink struct {
+	logger log.Logger
+	level  record.Level
+}
+
+func NewLogSink(l log.Logger, lvl record.Level) Sink {
+	return &logSink{logger: l, level: lvl}
+}
+
+func (ls *logSink) ReportSlowQuery(ctx context.Context, sq SlowQuery) {
+	ls.logger.WithContext(ctx).WithFields(
+		log.Field("fingerprint", sq.Fingerprint),
+		log.Field("duration", sq.Duration),
+	).Logf(ls.level, "slow query: %s", sq.Statement)
+}
+
+func (ls *logSink) ReportRepeatedQuery(ctx context.Context, rq RepeatedQuery) {
+	ls.logger.WithContext(ctx).WithFields(
+		log.Field("count", rq.Count),
+		log.Field("total", rq.Total),
+		log.Field("scope", rq.Scope),
+	).Logf(ls.level, "query repeated %d times: %s", rq.Count, rq.Fingerprint)
+}
@@ -0,0 +1,117 @@
+package logger
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/upfluence/log"
+	"github.com/upfluence/log/record"
+
+	"github.com/upfluence/sql"
+)
+
+// Logger is the legacy interface, it is still supported through
+// AdaptLogger but does not receive the error, the row count nor the
+// transaction identity.
+type Logger interface {
+	Log(OpType, string, []interface{}, time.Duration)
+}
+
+// Entry describes one operation executed against the DB. Duration is
+// measured until the Scanner is scanned for QueryRow and until the Cursor is
+// closed for Query.
+type Entry struct {
+	Op        OpType
+	Statement string
+	Args      []interface{}
+	Duration  time.Duration
+	Err       error
+
+	// Rows holds the number of rows affected for Exec and the number of
+	// rows scanned for Query and QueryRow, it is -1 when unknown.
+	Rows int64
+
+	// TxID identifies the transaction the operation belongs to, it is 0
+	// outside of a transaction.
+	TxID uint64
+
+	Tags []sql.QueryTag
+}
+
+type EntryLogger interface {
+	LogEntry(Entry)
+}
+
+type EntryLoggerFunc func(Entry)
+
+func (fn EntryLoggerFunc) LogEntry(e Entry) { fn(e) }
+
+type legacyLogger struct {
+	l Logger
+}
+
+func (ll legacyLogger) LogEntry(e Entry) {
+	ll.l.Log(e.Op, e.Statement, e.Args, e.Duration)
+}
+
+func AdaptLogger(l Logger) EntryLogger {
+	if el, ok := l.(EntryLogger); ok {
+		return el
+	}
+
+	return legacyLogger{l: l}
+}
+
+type simplifiedLogger struct {
+	level  record.Level
+	logger log.Logger
+}
+
+type durationField struct {
+	d time.Duration
+}
+
+func (d *durationField) GetKey() string   { return "duration" }
+func (d *durationField) GetValue() string { return fmt.Sprintf("%v", d.d) }
+
+type dynamicField struct {
+	name  string
+	value interface{}
+}
+
+func (d *dynamicField) GetKey() string   { return d.name }
+func (d *dynamicField) GetValue() string { return fmt.Sprintf("%v", d.value) }
+
+func (l *simplifiedLogger) Log(op OpType, q string, vs []interface{}, d time.Duration) {
+	l.LogEntry(Entry{Op: op, Statement: q, Args: vs, Duration: d, Rows: -1})
+}
+
+func (l *simplifiedLogger) LogEntry(e Entry) {
+	var fs = make([]record.Field, 0, len(e.Args)+len(e.Tags)+3)
+
+	fs = append(fs, &durationField{e.Duration})
+
+	if e.Rows >= 0 {
+		fs = append(fs, &dynamicField{name: "rows", value: e.Rows})
+	}
+
+	if e.TxID > 0 {
+		fs = append(fs, &dynamicField{name: "tx", value: e.TxID})
+	}
+
+	for _, t := range e.Tags {
+		fs = append(fs, &dynamicField{name: t.Key, value: t.Value})
+	}
+
+	for i, v := range e.Args {
+		fs = append(fs, &dynamicField{name: fmt.Sprintf("$%d", i+1), value: v})
+	}
+
+	logger := l.logger.WithFields(fs...)
+
+	if e.Err != nil {
+		logger = logger.WithError(e.Err)
+	}
+
+	logger.Log(l.level, e.Statement)
+}
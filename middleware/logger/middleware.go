@@ -2,7 +2,7 @@ package logger
 
 import (
 	"context"
-	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/upfluence/log"
@@ -19,62 +19,49 @@ const (
 	Query    OpType = "Query"
 	Commit   OpType = "Commit"
 	Rollback OpType = "Rollback"
+
+	Savepoint        OpType = "Savepoint"
+	ReleaseSavepoint OpType = "ReleaseSavepoint"
+	RollbackTo       OpType = "RollbackTo"
 )
 
-type Logger interface {
-	Log(OpType, string, []interface{}, time.Duration)
-}
+type Option func(*factory)
 
-type simplifiedLogger struct {
-	level  record.Level
-	logger log.Logger
+func WithRedactionRules(rs ...RedactionRule) Option {
+	return func(f *factory) { f.rules = append(f.rules, rs...) }
 }
 
-type durationField struct {
-	d time.Duration
-}
-
-func (d *durationField) GetKey() string   { return "duration" }
-func (d *durationField) GetValue() string { return fmt.Sprintf("%v", d.d) }
-
-type dynamicField struct {
-	name  string
-	value interface{}
+func NewFactory(l Logger, opts ...Option) sql.MiddlewareFactory {
+	return NewEntryFactory(AdaptLogger(l), opts...)
 }
 
-func (d *dynamicField) GetKey() string   { return d.name }
-func (d *dynamicField) GetValue() string { return fmt.Sprintf("%v", d.value) }
+func NewEntryFactory(l EntryLogger, opts ...Option) sql.MiddlewareFactory {
+	f := factory{l: l}
 
-func (l *simplifiedLogger) Log(_ OpType, q string, vs []interface{}, d time.Duration) {
-	var fs = make([]record.Field, len(vs)+1)
-
-	fs[0] = &durationField{d}
-
-	for i, v := range vs {
-		fs[i+1] = &dynamicField{name: fmt.Sprintf("$%d", i+1), value: v}
+	for _, opt := range opts {
+		opt(&f)
 	}
 
-	l.logger.WithFields(fs...).Log(l.level, q)
-}
-
-func NewFactory(l Logger) sql.MiddlewareFactory {
-	return &factory{l: l}
+	return &f
 }
 
-func NewLevelFactory(l log.Logger, lvl record.Level) sql.MiddlewareFactory {
-	return NewFactory(&simplifiedLogger{logger: l, level: lvl})
+func NewLevelFactory(l log.Logger, lvl record.Level, opts ...Option) sql.MiddlewareFactory {
+	return NewEntryFactory(&simplifiedLogger{logger: l, level: lvl}, opts...)
 }
 
-func NewDebugFactory(l log.Logger) sql.MiddlewareFactory {
-	return NewLevelFactory(l, record.Debug)
+func NewDebugFactory(l log.Logger, opts ...Option) sql.MiddlewareFactory {
+	return NewLevelFactory(l, record.Debug, opts...)
 }
 
 type factory struct {
-	l Logger
+	l     EntryLogger
+	rules []RedactionRule
+
+	txID uint64
 }
 
 func (f *factory) Wrap(d sql.DB) sql.DB {
-	return &db{queryer: &queryer{Queryer: d, l: f.l}, db: d}
+	return &db{queryer: &queryer{Queryer: d, f: f}, db: d}
 }
 
 type db struct {
@@ -85,6 +72,16 @@ type db struct {
 
 func (d *db) Driver() string { return d.db.Driver() }
 
+func (d *db) Stats() []sql.NodeStats { return sql.NodeStatsOf("", d.db) }
+
+func (d *db) StatementCacheStats() sql.StatementCacheStats {
+	return sql.StatementCacheStatsOf(d.db)
+}
+
+func (d *db) Ping(ctx context.Context) []sql.NodeHealth {
+	return sql.PingNodes(ctx, "", d.db)
+}
+
 func (d *db) BeginTx(ctx context.Context, opts sql.TxOptions) (sql.Tx, error) {
 	var t, err = d.db.BeginTx(ctx, opts)
 
@@ -92,60 +89,197 @@ func (d *db) BeginTx(ctx context.Context, opts sql.TxOptions) (sql.Tx, error) {
 		return nil, err
 	}
 
-	return &tx{queryer: &queryer{Queryer: t, l: d.queryer.l}, tx: t}, nil
+	return &tx{
+		queryer: &queryer{
+			Queryer: t,
+			f:       d.f,
+			txID:    atomic.AddUint64(&d.f.txID, 1),
+		},
+		tx:  t,
+		ctx: ctx,
+	}, nil
 }
 
 type tx struct {
 	*queryer
 
-	tx sql.Tx
+	tx  sql.Tx
+	ctx context.Context
 }
 
 func (t *tx) Commit() error {
 	var t0 = time.Now()
 
-	defer t.queryer.logRequest(Commit, t0, "COMMIT", nil)
+	err := t.tx.Commit()
+	t.log(t.ctx, Commit, t0, "COMMIT", nil, -1, err)
 
-	return t.tx.Commit()
+	return err
 }
 
 func (t *tx) Rollback() error {
 	var t0 = time.Now()
 
-	defer t.queryer.logRequest(Rollback, t0, "Rollback", nil)
+	err := t.tx.Rollback()
+	t.log(t.ctx, Rollback, t0, "ROLLBACK", nil, -1, err)
+
+	return err
+}
 
-	return t.tx.Rollback()
+func (t *tx) Savepoint(ctx context.Context, name string) error {
+	return t.execSavepoint(ctx, Savepoint, "SAVEPOINT", name, func(sp sql.Savepointer) error {
+		return sp.Savepoint(ctx, name)
+	})
+}
+
+func (t *tx) ReleaseSavepoint(ctx context.Context, name string) error {
+	return t.execSavepoint(ctx, ReleaseSavepoint, "RELEASE SAVEPOINT", name, func(sp sql.Savepointer) error {
+		return sp.ReleaseSavepoint(ctx, name)
+	})
+}
+
+func (t *tx) RollbackTo(ctx context.Context, name string) error {
+	return t.execSavepoint(ctx, RollbackTo, "ROLLBACK TO SAVEPOINT", name, func(sp sql.Savepointer) error {
+		return sp.RollbackTo(ctx, name)
+	})
+}
+
+func (t *tx) execSavepoint(ctx context.Context, op OpType, cmd, name string, fn func(sql.Savepointer) error) error {
+	sp, ok := t.tx.(sql.Savepointer)
+
+	if !ok {
+		return sql.ErrSavepointNotSupported
+	}
+
+	var t0 = time.Now()
+
+	err := fn(sp)
+	t.log(ctx, op, t0, cmd+" "+name, nil, -1, err)
+
+	return err
 }
 
 type queryer struct {
 	sql.Queryer
-	l Logger
+
+	f    *factory
+	txID uint64
 }
 
-func (q *queryer) logRequest(t OpType, t0 time.Time, qry string, vs []interface{}) {
-	q.l.Log(t, qry, sql.StripOptions(vs), time.Since(t0))
+func (q *queryer) log(ctx context.Context, op OpType, t0 time.Time, qry string, vs []interface{}, rows int64, err error) {
+	q.f.l.LogEntry(
+		Entry{
+			Op:        op,
+			Statement: qry,
+			Args:      redactArgs(q.f.rules, qry, sql.StripOptions(vs)),
+			Duration:  time.Since(t0),
+			Err:       err,
+			Rows:      rows,
+			TxID:      q.txID,
+			Tags:      sql.QueryTags(ctx),
+		},
+	)
 }
 
 func (q *queryer) Exec(ctx context.Context, qry string, vs ...interface{}) (sql.Result, error) {
-	var t0 = time.Now()
+	var (
+		t0   = time.Now()
+		rows = int64(-1)
+	)
 
-	defer q.logRequest(Exec, t0, qry, vs)
+	res, err := q.Queryer.Exec(ctx, qry, vs...)
+
+	if err == nil {
+		if n, err := res.RowsAffected(); err == nil {
+			rows = n
+		}
+	}
 
-	return q.Queryer.Exec(ctx, qry, vs...)
+	q.log(ctx, Exec, t0, qry, vs, rows, err)
+
+	return res, err
 }
 
 func (q *queryer) QueryRow(ctx context.Context, qry string, vs ...interface{}) sql.Scanner {
 	var t0 = time.Now()
 
-	defer q.logRequest(QueryRow, t0, qry, vs)
-
-	return q.Queryer.QueryRow(ctx, qry, vs...)
+	return &scanner{
+		Scanner: q.Queryer.QueryRow(ctx, qry, vs...),
+		ctx:     ctx,
+		q:       q,
+		qry:     qry,
+		vs:      vs,
+		t0:      t0,
+	}
 }
 
 func (q *queryer) Query(ctx context.Context, qry string, vs ...interface{}) (sql.Cursor, error) {
 	var t0 = time.Now()
 
-	defer q.logRequest(Query, t0, qry, vs)
+	cur, err := q.Queryer.Query(ctx, qry, vs...)
+
+	if err != nil {
+		q.log(ctx, Query, t0, qry, vs, -1, err)
+		return nil, err
+	}
+
+	return &cursor{Cursor: cur, ctx: ctx, q: q, qry: qry, vs: vs, t0: t0}, nil
+}
+
+type scanner struct {
+	sql.Scanner
+
+	ctx context.Context
+	q   *queryer
+	qry string
+	vs  []interface{}
+	t0  time.Time
+}
+
+func (sc *scanner) Scan(vs ...interface{}) error {
+	var rows int64
+
+	err := sc.Scanner.Scan(vs...)
+
+	if err == nil {
+		rows = 1
+	}
+
+	sc.q.log(sc.ctx, QueryRow, sc.t0, sc.qry, sc.vs, rows, err)
+
+	return err
+}
+
+type cursor struct {
+	sql.Cursor
+
+	ctx  context.Context
+	q    *queryer
+	qry  string
+	vs   []interface{}
+	t0   time.Time
+	rows int64
+}
+
+func (c *cursor) Scan(vs ...interface{}) error {
+	err := c.Cursor.Scan(vs...)
+
+	if err == nil {
+		c.rows++
+	}
+
+	return err
+}
+
+func (c *cursor) Close() error {
+	err := c.Cursor.Close()
+
+	lerr := c.Cursor.Err()
+
+	if lerr == nil {
+		lerr = err
+	}
+
+	c.q.log(c.ctx, Query, c.t0, c.qry, c.vs, c.rows, lerr)
 
-	return q.Queryer.Query(ctx, qry, vs...)
+	return err
 }
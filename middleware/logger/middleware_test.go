@@ -2,6 +2,8 @@ package logger
 
 import (
 	"context"
+	"errors"
+	"regexp"
 	"testing"
 	"time"
 
@@ -11,14 +13,6 @@ import (
 	"github.com/upfluence/sql/backend/static"
 )
 
-type emptyCursor struct {
-	sql.Cursor
-}
-
-type emptyScanner struct {
-	sql.Scanner
-}
-
 type logEvent struct {
 	op   OpType
 	qs   string
@@ -33,6 +27,15 @@ func (ml *mockLogger) Log(op OpType, qs string, args []interface{}, _ time.Durat
 	ml.event = logEvent{op: op, qs: qs, args: args}
 }
 
+type mockEntryLogger struct {
+	entries []Entry
+}
+
+func (mel *mockEntryLogger) LogEntry(e Entry) {
+	e.Duration = 0
+	mel.entries = append(mel.entries, e)
+}
+
 func TestQueryer(t *testing.T) {
 	var args = []interface{}{"bar", sql.StronglyConsistent}
 
@@ -44,11 +47,7 @@ func TestQueryer(t *testing.T) {
 		{
 			op: QueryRow,
 			call: func(t *testing.T, db sql.DB) error {
-				sc := db.QueryRow(context.Background(), "foo", args...)
-
-				assert.Equal(t, &emptyScanner{}, sc)
-
-				return nil
+				return db.QueryRow(context.Background(), "foo", args...).Scan()
 			},
 			arg: func(db static.DB) []static.Query { return db.QueryRowQueries },
 		},
@@ -68,9 +67,11 @@ func TestQueryer(t *testing.T) {
 			call: func(t *testing.T, db sql.DB) error {
 				cursor, err := db.Query(context.Background(), "foo", args...)
 
-				assert.Equal(t, &emptyCursor{}, cursor)
+				if err != nil {
+					return err
+				}
 
-				return err
+				return cursor.Close()
 			},
 			arg: func(db static.DB) []static.Query { return db.QueryQueries },
 		},
@@ -79,8 +80,8 @@ func TestQueryer(t *testing.T) {
 			var (
 				db = &static.DB{
 					Queryer: static.Queryer{
-						QueryRowScanner: &emptyScanner{},
-						QueryScanner:    &emptyCursor{},
+						QueryRowScanner: static.Scanner{},
+						QueryScanner:    &static.SingleCursor{},
 						ExecResult:      sql.StaticResult(1),
 					},
 				}
@@ -99,3 +100,63 @@ func TestQueryer(t *testing.T) {
 		})
 	}
 }
+
+func TestEntryLogger(t *testing.T) {
+	var (
+		ctx = context.Background()
+		err = errors.New("scan failed")
+
+		sdb = &static.DB{
+			Queryer: static.Queryer{
+				QueryRowScanner: static.Scanner{Err: err},
+				QueryScanner: &static.MultipleCursor{
+					Scanners: []static.Scanner{{}, {}},
+				},
+			},
+			Tx: &static.Tx{
+				Queryer: static.Queryer{ExecResult: sql.StaticResult(1)},
+			},
+		}
+		mel = &mockEntryLogger{}
+
+		db = NewEntryFactory(
+			mel,
+			WithRedactionRules(
+				RedactArguments(regexp.MustCompile("password"), 2),
+			),
+		).Wrap(sdb)
+	)
+
+	sc := db.QueryRow(ctx, "SELECT 1 WHERE password = $1")
+	assert.Len(t, mel.entries, 0)
+	assert.Equal(t, err, sc.Scan())
+
+	cur, _ := db.Query(ctx, "SELECT 2")
+
+	for cur.Next() {
+		assert.NoError(t, cur.Scan())
+	}
+
+	assert.NoError(t, cur.Close())
+
+	tx, _ := db.BeginTx(ctx, sql.TxOptions{})
+	tx.Exec(ctx, "UPDATE foo SET password = $2 WHERE id = $1", 1, "secret")
+	tx.Commit()
+
+	assert.Equal(
+		t,
+		[]Entry{
+			{Op: QueryRow, Statement: "SELECT 1 WHERE password = $1", Err: err},
+			{Op: Query, Statement: "SELECT 2", Rows: 2},
+			{
+				Op:        Exec,
+				Statement: "UPDATE foo SET password = $2 WHERE id = $1",
+				Args:      []interface{}{1, RedactedValue},
+				Rows:      1,
+				TxID:      1,
+			},
+			{Op: Commit, Statement: "COMMIT", Rows: -1, TxID: 1},
+		},
+		mel.entries,
+	)
+}
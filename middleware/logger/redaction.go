@@ -0,0 +1,69 @@
+package logger
+
+import "regexp"
+
+const RedactedValue = "[REDACTED]"
+
+// RedactionRule decides whether the argument at the given index (starting
+// at 0) of a statement must be hidden from the logs.
+type RedactionRule interface {
+	Redact(string, int, interface{}) bool
+}
+
+type RedactionRuleFunc func(string, int, interface{}) bool
+
+func (fn RedactionRuleFunc) Redact(stmt string, i int, v interface{}) bool {
+	return fn(stmt, i, v)
+}
+
+// RedactAll hides every argument.
+var RedactAll RedactionRule = RedactionRuleFunc(
+	func(string, int, interface{}) bool { return true },
+)
+
+// RedactStatements hides every argument of the statements matching the
+// regexp.
+func RedactStatements(re *regexp.Regexp) RedactionRule {
+	return RedactionRuleFunc(func(stmt string, _ int, _ interface{}) bool {
+		return re.MatchString(stmt)
+	})
+}
+
+// RedactArguments hides the arguments at the given positions ($1 being the
+// position 1) of the statements matching the regexp.
+func RedactArguments(re *regexp.Regexp, positions ...int) RedactionRule {
+	ps := make(map[int]struct{}, len(positions))
+
+	for _, p := range positions {
+		ps[p-1] = struct{}{}
+	}
+
+	return RedactionRuleFunc(func(stmt string, i int, _ interface{}) bool {
+		if _, ok := ps[i]; !ok {
+			return false
+		}
+
+		return re.MatchString(stmt)
+	})
+}
+
+func redactArgs(rs []RedactionRule, stmt string, vs []interface{}) []interface{} {
+	if len(rs) == 0 || len(vs) == 0 {
+		return vs
+	}
+
+	res := make([]interface{}, len(vs))
+
+	for i, v := range vs {
+		res[i] = v
+
+		for _, r := range rs {
+			if r.Redact(stmt, i, v) {
+				res[i] = RedactedValue
+				break
+			}
+		}
+	}
+
+	return res
+}
@@ -0,0 +1,276 @@
+package metrics
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/upfluence/sql"
+	"github.com/upfluence/sql/sqlparser"
+)
+
+type OpType string
+
+const (
+	Exec     OpType = "Exec"
+	QueryRow OpType = "QueryRow"
+	Query    OpType = "Query"
+	Commit   OpType = "Commit"
+	Rollback OpType = "Rollback"
+)
+
+type QueryLabels struct {
+	Op        OpType
+	Statement string
+	Driver    string
+	Error     sql.ErrorClass
+}
+
+type TxLabels struct {
+	Op     OpType
+	Driver string
+	Error  sql.ErrorClass
+}
+
+// Collector is the bridge between the middleware and the metrics backend.
+// Each Observe call is expected to both increment the operation counter and
+// record the latency in the histogram.
+type Collector interface {
+	ObserveQuery(QueryLabels, time.Duration)
+	ObserveTx(TxLabels, time.Duration)
+
+	// AddInFlightTx adjusts the gauge of the transactions currently opened
+	// for the given driver.
+	AddInFlightTx(string, int)
+}
+
+type Option func(*factory)
+
+// WithNormalizer overrides the function turning a statement into the value
+// of the statement label.
+func WithNormalizer(fn func(string) string) Option {
+	return func(f *factory) { f.normalize = fn }
+}
+
+func NewFactory(c Collector, opts ...Option) sql.MiddlewareFactory {
+	f := factory{c: c, normalize: sqlparser.Normalize}
+
+	for _, opt := range opts {
+		opt(&f)
+	}
+
+	return &f
+}
+
+type factory struct {
+	c         Collector
+	normalize func(string) string
+}
+
+func (f *factory) Wrap(d sql.DB) sql.DB {
+	return &db{queryer: &queryer{q: d, f: f, driver: d.Driver()}, db: d}
+}
+
+type db struct {
+	*queryer
+
+	db sql.DB
+}
+
+func (d *db) Driver() string { return d.db.Driver() }
+
+func (d *db) Stats() []sql.NodeStats { return sql.NodeStatsOf("", d.db) }
+
+func (d *db) StatementCacheStats() sql.StatementCacheStats {
+	return sql.StatementCacheStatsOf(d.db)
+}
+
+func (d *db) Ping(ctx context.Context) []sql.NodeHealth {
+	return sql.PingNodes(ctx, "", d.db)
+}
+
+func (d *db) BeginTx(ctx context.Context, opts sql.TxOptions) (sql.Tx, error) {
+	var t, err = d.db.BeginTx(ctx, opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	d.f.c.AddInFlightTx(d.driver, 1)
+
+	return &tx{
+		queryer: &queryer{q: t, f: d.f, driver: d.driver},
+		tx:      t,
+		t0:      time.Now(),
+	}, nil
+}
+
+type tx struct {
+	*queryer
+
+	tx sql.Tx
+	t0 time.Time
+
+	once sync.Once
+}
+
+func (t *tx) observe(op OpType, err error) {
+	t.once.Do(func() {
+		t.f.c.AddInFlightTx(t.driver, -1)
+		t.f.c.ObserveTx(
+			TxLabels{Op: op, Driver: t.driver, Error: sql.ClassifyError(err)},
+			time.Since(t.t0),
+		)
+	})
+}
+
+func (t *tx) Commit() error {
+	err := t.tx.Commit()
+
+	t.observe(Commit, err)
+
+	return err
+}
+
+func (t *tx) Rollback() error {
+	err := t.tx.Rollback()
+
+	t.observe(Rollback, err)
+
+	return err
+}
+
+func (t *tx) Savepoint(ctx context.Context, name string) error {
+	sp, ok := t.tx.(sql.Savepointer)
+
+	if !ok {
+		return sql.ErrSavepointNotSupported
+	}
+
+	return sp.Savepoint(ctx, name)
+}
+
+func (t *tx) ReleaseSavepoint(ctx context.Context, name string) error {
+	sp, ok := t.tx.(sql.Savepointer)
+
+	if !ok {
+		return sql.ErrSavepointNotSupported
+	}
+
+	return sp.ReleaseSavepoint(ctx, name)
+}
+
+func (t *tx) RollbackTo(ctx context.Context, name string) error {
+	sp, ok := t.tx.(sql.Savepointer)
+
+	if !ok {
+		return sql.ErrSavepointNotSupported
+	}
+
+	return sp.RollbackTo(ctx, name)
+}
+
+type queryer struct {
+	q sql.Queryer
+	f *factory
+
+	driver string
+}
+
+func (q *queryer) observe(op OpType, stmt string, t0 time.Time, err error) {
+	q.f.c.ObserveQuery(
+		QueryLabels{
+			Op:        op,
+			Statement: q.f.normalize(stmt),
+			Driver:    q.driver,
+			Error:     sql.ClassifyError(err),
+		},
+		time.Since(t0),
+	)
+}
+
+func (q *queryer) Exec(ctx context.Context, stmt string, vs ...interface{}) (sql.Result, error) {
+	var t0 = time.Now()
+
+	res, err := q.q.Exec(ctx, stmt, vs...)
+
+	q.observe(Exec, stmt, t0, err)
+
+	return res, err
+}
+
+func (q *queryer) QueryRow(ctx context.Context, stmt string, vs ...interface{}) sql.Scanner {
+	var t0 = time.Now()
+
+	return &scanner{
+		sc:   q.q.QueryRow(ctx, stmt, vs...),
+		q:    q,
+		stmt: stmt,
+		t0:   t0,
+	}
+}
+
+func (q *queryer) Query(ctx context.Context, stmt string, vs ...interface{}) (sql.Cursor, error) {
+	var t0 = time.Now()
+
+	cur, err := q.q.Query(ctx, stmt, vs...)
+
+	if err != nil {
+		q.observe(Query, stmt, t0, err)
+		return nil, err
+	}
+
+	return &cursor{Cursor: cur, q: q, stmt: stmt, t0: t0}, nil
+}
+
+type scanner struct {
+	sc sql.Scanner
+	q  *queryer
+
+	stmt string
+	t0   time.Time
+}
+
+func (sc *scanner) Scan(vs ...interface{}) error {
+	err := sc.sc.Scan(vs...)
+
+	sc.q.observe(QueryRow, sc.stmt, sc.t0, err)
+
+	return err
+}
+
+type cursor struct {
+	sql.Cursor
+
+	q *queryer
+
+	stmt string
+	t0   time.Time
+	err  error
+}
+
+func (c *cursor) Scan(vs ...interface{}) error {
+	err := c.Cursor.Scan(vs...)
+
+	if err != nil && c.err == nil {
+		c.err = err
+	}
+
+	return err
+}
+
+func (c *cursor) Close() error {
+	err := c.Cursor.Close()
+
+	switch {
+	case c.err != nil:
+	case c.Cursor.Err() != nil:
+		c.err = c.Cursor.Err()
+	default:
+		c.err = err
+	}
+
+	c.q.observe(Query, c.stmt, c.t0, c.err)
+
+	return err
+}
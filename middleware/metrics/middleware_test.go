@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/upfluence/sql"
+	"github.com/upfluence/sql/backend/static"
+)
+
+type mockCollector struct {
+	queries  []QueryLabels
+	txs      []TxLabels
+	inFlight int
+}
+
+func (mc *mockCollector) ObserveQuery(l QueryLabels, _ time.Duration) {
+	mc.queries = append(mc.queries, l)
+}
+
+func (mc *mockCollector) ObserveTx(l TxLabels, _ time.Duration) {
+	mc.txs = append(mc.txs, l)
+}
+
+func (mc *mockCollector) AddInFlightTx(_ string, d int) { mc.inFlight += d }
+
+func TestQueryer(t *testing.T) {
+	var (
+		ctx = context.Background()
+		q   = static.Queryer{
+			QueryRowScanner: static.Scanner{Err: sql.ErrNoRows},
+			QueryScanner:    &static.SingleCursor{},
+			ExecResult:      sql.StaticResult(1),
+			ExecErr: sql.ConstraintError{
+				Type:  sql.Unique,
+				Cause: errors.New("dup"),
+			},
+		}
+		mc = mockCollector{}
+		db = NewFactory(&mc).Wrap(&static.DB{Queryer: q})
+	)
+
+	_, err := db.Exec(ctx, "INSERT INTO foo\n\tVALUES ($1)", 1)
+	assert.Error(t, err)
+
+	sc := db.QueryRow(ctx, "SELECT foo")
+	assert.Len(t, mc.queries, 1)
+	assert.Equal(t, sql.ErrNoRows, sc.Scan())
+
+	cur, err := db.Query(ctx, "SELECT  bar")
+	assert.NoError(t, err)
+	assert.Len(t, mc.queries, 2)
+	assert.NoError(t, cur.Close())
+
+	assert.Equal(
+		t,
+		[]QueryLabels{
+			{
+				Op:        Exec,
+				Statement: "INSERT INTO foo VALUES (?)",
+				Driver:    "sqltest",
+				Error:     sql.ConstraintErrorClass,
+			},
+			{Op: QueryRow, Statement: "SELECT foo", Driver: "sqltest", Error: sql.NoRowsErrorClass},
+			{Op: Query, Statement: "SELECT bar", Driver: "sqltest"},
+		},
+		mc.queries,
+	)
+}
+
+func TestTx(t *testing.T) {
+	var (
+		ctx = context.Background()
+		mc  = mockCollector{}
+		db  = NewFactory(&mc).Wrap(
+			&static.DB{
+				Tx: &static.Tx{
+					CommitErr: sql.RollbackError{
+						Type:  sql.SerializationFailure,
+						Cause: errors.New("serialization"),
+					},
+				},
+			},
+		)
+	)
+
+	tx, err := db.BeginTx(ctx, sql.TxOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, mc.inFlight)
+
+	assert.Error(t, tx.Commit())
+	assert.NoError(t, tx.Rollback())
+
+	assert.Equal(t, 0, mc.inFlight)
+	assert.Equal(
+		t,
+		[]TxLabels{{Op: Commit, Driver: "sqltest", Error: sql.RollbackErrorClass}},
+		mc.txs,
+	)
+}
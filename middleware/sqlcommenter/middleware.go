@@ -0,0 +1,295 @@
+package sqlcommenter
+
+import (
+	"context"
+	"net/url"
+	"runtime"
+	"sort"
+	"strings"
+
+	"github.com/upfluence/sql"
+	"github.com/upfluence/sql/sqlparser"
+)
+
+const (
+	CallerKey = "caller"
+
+	sqlPackagePrefix = "github.com/upfluence/sql"
+)
+
+type Position uint8
+
+const (
+	Append Position = iota
+	Prepend
+)
+
+// Tagger returns the tags to attach to the queries executed with the
+// context, typically the trace id of the current span.
+type Tagger func(context.Context) []sql.QueryTag
+
+type Option func(*factory)
+
+// WithTag attaches a static tag to every query, such as the service name.
+func WithTag(k, v string) Option {
+	return func(f *factory) {
+		f.tags = append(f.tags, sql.QueryTag{Key: k, Value: v})
+	}
+}
+
+func WithTagger(t Tagger) Option {
+	return func(f *factory) { f.taggers = append(f.taggers, t) }
+}
+
+// WithCaller tags the queries with the name of the function executing them,
+// the frames belonging to this module, tests aside, are skipped.
+func WithCaller() Option {
+	return func(f *factory) { f.caller = true }
+}
+
+// WithPosition sets where the comment is inserted in the statement, it is
+// appended by default.
+func WithPosition(p Position) Option {
+	return func(f *factory) { f.position = p }
+}
+
+// NewFactory returns a middleware adding to each statement a comment
+// following the sqlcommenter format: /*key='value',...*/. The tags are
+// collected from the options and from sql.QueryTags of the query context,
+// the latter taking precedence. Statements already holding a comment are
+// left untouched, the ones holding per query tags are marked with
+// sql.NoStatementCache.
+func NewFactory(opts ...Option) sql.MiddlewareFactory {
+	var f factory
+
+	for _, opt := range opts {
+		opt(&f)
+	}
+
+	return &f
+}
+
+type factory struct {
+	tags     []sql.QueryTag
+	taggers  []Tagger
+	caller   bool
+	position Position
+}
+
+func (f *factory) Wrap(d sql.DB) sql.DB {
+	return &db{queryer: &queryer{q: d, f: f}, db: d}
+}
+
+// collectTags returns the tags of the query and whether some of them vary
+// from one query to the other.
+func (f *factory) collectTags(ctx context.Context) ([]sql.QueryTag, bool) {
+	var (
+		tags = make(map[string]string, len(f.tags))
+
+		dynamic bool
+	)
+
+	for _, t := range f.tags {
+		tags[t.Key] = t.Value
+	}
+
+	for _, tfn := range f.taggers {
+		for _, t := range tfn(ctx) {
+			tags[t.Key] = t.Value
+			dynamic = true
+		}
+	}
+
+	if f.caller {
+		if c := caller(); c != "" {
+			tags[CallerKey] = c
+			dynamic = true
+		}
+	}
+
+	for _, t := range sql.QueryTags(ctx) {
+		tags[t.Key] = t.Value
+		dynamic = true
+	}
+
+	res := make([]sql.QueryTag, 0, len(tags))
+
+	for k, v := range tags {
+		res = append(res, sql.QueryTag{Key: k, Value: v})
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].Key < res[j].Key })
+
+	return res, dynamic
+}
+
+// comment returns the statement holding the comment and whether its text is
+// unique. The per query tags, such as the caller or the trace id, make every
+// statement text unique: such statements are kept out of the prepared
+// statement caches as they would only churn them.
+func (f *factory) comment(ctx context.Context, stmt string) (string, bool) {
+	tags, dynamic := f.collectTags(ctx)
+
+	if len(tags) == 0 || hasComment(stmt) {
+		return stmt, false
+	}
+
+	var b strings.Builder
+
+	b.WriteString("/*")
+
+	for i, t := range tags {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+
+		b.WriteString(encode(t.Key))
+		b.WriteString("='")
+		b.WriteString(encode(t.Value))
+		b.WriteByte('\'')
+	}
+
+	b.WriteString("*/")
+
+	if f.position == Prepend {
+		return b.String() + " " + stmt, dynamic
+	}
+
+	return strings.TrimRight(stmt, " \t\n;") + " " + b.String(), dynamic
+}
+
+// encode URL encodes the value as required by the sqlcommenter
+// specification, which also escapes the single quotes it may hold.
+func encode(v string) string { return url.PathEscape(v) }
+
+func hasComment(stmt string) bool {
+	l := sqlparser.NewLexer(stmt)
+
+	for t := l.Next(); t.Kind != sqlparser.TokenEOF; t = l.Next() {
+		if t.Kind == sqlparser.TokenComment {
+			return true
+		}
+	}
+
+	return false
+}
+
+func caller() string {
+	var pcs [16]uintptr
+
+	frames := runtime.CallersFrames(pcs[:runtime.Callers(3, pcs[:])])
+
+	for {
+		f, more := frames.Next()
+
+		if strings.HasSuffix(f.File, "_test.go") ||
+			(!strings.HasPrefix(f.Function, sqlPackagePrefix+".") &&
+				!strings.HasPrefix(f.Function, sqlPackagePrefix+"/")) {
+			return f.Function
+		}
+
+		if !more {
+			return ""
+		}
+	}
+}
+
+type db struct {
+	*queryer
+
+	db sql.DB
+}
+
+func (d *db) Driver() string { return d.db.Driver() }
+
+func (d *db) Stats() []sql.NodeStats { return sql.NodeStatsOf("", d.db) }
+
+func (d *db) StatementCacheStats() sql.StatementCacheStats {
+	return sql.StatementCacheStatsOf(d.db)
+}
+
+func (d *db) Ping(ctx context.Context) []sql.NodeHealth {
+	return sql.PingNodes(ctx, "", d.db)
+}
+
+func (d *db) BeginTx(ctx context.Context, opts sql.TxOptions) (sql.Tx, error) {
+	t, err := d.db.BeginTx(ctx, opts)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &tx{queryer: &queryer{q: t, f: d.f}, tx: t}, nil
+}
+
+type tx struct {
+	*queryer
+
+	tx sql.Tx
+}
+
+func (t *tx) Commit() error   { return t.tx.Commit() }
+func (t *tx) Rollback() error { return t.tx.Rollback() }
+
+func (t *tx) Savepoint(ctx context.Context, name string) error {
+	sp, ok := t.tx.(sql.Savepointer)
+
+	if !ok {
+		return sql.ErrSavepointNotSupported
+	}
+
+	return sp.Savepoint(ctx, name)
+}
+
+func (t *tx) ReleaseSavepoint(ctx context.Context, name string) error {
+	sp, ok := t.tx.(sql.Savepointer)
+
+	if !ok {
+		return sql.ErrSavepointNotSupported
+	}
+
+	return sp.ReleaseSavepoint(ctx, name)
+}
+
+func (t *tx) RollbackTo(ctx context.Context, name string) error {
+	sp, ok := t.tx.(sql.Savepointer)
+
+	if !ok {
+		return sql.ErrSavepointNotSupported
+	}
+
+	return sp.RollbackTo(ctx, name)
+}
+
+type queryer struct {
+	q sql.Queryer
+	f *factory
+}
+
+func (q *queryer) comment(ctx context.Context, stmt string, vs []interface{}) (string, []interface{}) {
+	stmt, unique := q.f.comment(ctx, stmt)
+
+	if unique {
+		vs = append(vs[:len(vs):len(vs)], sql.NoStatementCache{})
+	}
+
+	return stmt, vs
+}
+
+func (q *queryer) Exec(ctx context.Context, stmt string, vs ...interface{}) (sql.Result, error) {
+	stmt, vs = q.comment(ctx, stmt, vs)
+
+	return q.q.Exec(ctx, stmt, vs...)
+}
+
+func (q *queryer) QueryRow(ctx context.Context, stmt string, vs ...interface{}) sql.Scanner {
+	stmt, vs = q.comment(ctx, stmt, vs)
+
+	return q.q.QueryRow(ctx, stmt, vs...)
+}
+
+func (q *queryer) Query(ctx context.Context, stmt string, vs ...interface{}) (sql.Cursor, error) {
+	stmt, vs = q.comment(ctx, stmt, vs)
+
+	return q.q.Query(ctx, stmt, vs...)
+}
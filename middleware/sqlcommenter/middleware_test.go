@@ -0,0 +1,217 @@
+package sqlcommenter
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/upfluence/sql"
+	"github.com/upfluence/sql/backend/postgres"
+	"github.com/upfluence/sql/backend/replication"
+	"github.com/upfluence/sql/backend/static"
+	"github.com/upfluence/sql/sqlparser"
+)
+
+func TestComment(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		opts []Option
+		ctx  context.Context
+		in   string
+		out  string
+		args []interface{}
+	}{
+		{
+			name: "no tags",
+			ctx:  context.Background(),
+			in:   "SELECT 1",
+			out:  "SELECT 1",
+		},
+		{
+			name: "static tags",
+			opts: []Option{WithTag("service", "api"), WithTag("env", "prod")},
+			ctx:  context.Background(),
+			in:   "SELECT 1;",
+			out:  "SELECT 1 /*env='prod',service='api'*/",
+		},
+		{
+			name: "context tags override",
+			opts: []Option{WithTag("route", "default")},
+			ctx: sql.WithQueryTag(
+				context.Background(),
+				"route",
+				"/users/{id}",
+			),
+			in:   "SELECT 1",
+			out:  "SELECT 1 /*route='%2Fusers%2F%7Bid%7D'*/",
+			args: []interface{}{sql.NoStatementCache{}},
+		},
+		{
+			name: "tagger",
+			opts: []Option{
+				WithTagger(func(context.Context) []sql.QueryTag {
+					return []sql.QueryTag{{Key: "traceparent", Value: "00-abc-01"}}
+				}),
+			},
+			ctx:  context.Background(),
+			in:   "SELECT 1",
+			out:  "SELECT 1 /*traceparent='00-abc-01'*/",
+			args: []interface{}{sql.NoStatementCache{}},
+		},
+		{
+			name: "escaping",
+			opts: []Option{WithTag("na me", "it's")},
+			ctx:  context.Background(),
+			in:   "SELECT 1",
+			out:  "SELECT 1 /*na%20me='it%27s'*/",
+		},
+		{
+			name: "prepend",
+			opts: []Option{WithTag("service", "api"), WithPosition(Prepend)},
+			ctx:  context.Background(),
+			in:   "SELECT 1",
+			out:  "/*service='api'*/ SELECT 1",
+		},
+		{
+			name: "existing comment",
+			opts: []Option{WithTag("service", "api")},
+			ctx:  context.Background(),
+			in:   "SELECT 1 /* hand written */",
+			out:  "SELECT 1 /* hand written */",
+		},
+		{
+			name: "comment like string",
+			opts: []Option{WithTag("service", "api")},
+			ctx:  context.Background(),
+			in:   "SELECT '/* foo */'",
+			out:  "SELECT '/* foo */' /*service='api'*/",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var sdb static.DB
+
+			db := NewFactory(tt.opts...).Wrap(&sdb)
+
+			_, err := db.Exec(tt.ctx, tt.in)
+			assert.NoError(t, err)
+
+			assert.Len(t, sdb.ExecQueries, 1)
+			sdb.ExecQueries[0].Assert(t, tt.out, tt.args...)
+		})
+	}
+}
+
+func TestCaller(t *testing.T) {
+	var (
+		sdb static.DB
+
+		db = NewFactory(WithCaller()).Wrap(&sdb)
+	)
+
+	_, err := db.Exec(context.Background(), "SELECT 1")
+	assert.NoError(t, err)
+
+	assert.Len(t, sdb.ExecQueries, 1)
+	assert.Equal(
+		t,
+		"SELECT 1 /*caller='github.com%2Fupfluence%2Fsql%2Fmiddleware%2Fsqlcommenter.TestCaller'*/",
+		sdb.ExecQueries[0].Query,
+	)
+}
+
+func TestTx(t *testing.T) {
+	var (
+		ctx = context.Background()
+		sdb = static.DB{Tx: &static.Tx{}}
+
+		db = NewFactory(WithTag("service", "api")).Wrap(&sdb)
+	)
+
+	tx, err := db.BeginTx(ctx, sql.TxOptions{})
+	assert.NoError(t, err)
+
+	_, err = tx.Exec(ctx, "DELETE FROM foo")
+	assert.NoError(t, err)
+
+	assert.Equal(
+		t,
+		sql.ErrSavepointNotSupported,
+		tx.(sql.Savepointer).Savepoint(ctx, "sp"),
+	)
+
+	assert.NoError(t, tx.Commit())
+
+	stx := sdb.Tx.(*static.Tx)
+
+	assert.Len(t, stx.ExecQueries, 1)
+	stx.ExecQueries[0].Assert(t, "DELETE FROM foo /*service='api'*/")
+}
+
+func TestPostgresReturning(t *testing.T) {
+	for _, p := range []Position{Append, Prepend} {
+		var (
+			sdb = static.DB{
+				Queryer: static.Queryer{
+					QueryRowScanner: &static.Scanner{
+						Args: []static.ScanArg{static.Int64Arg(2)},
+					},
+				},
+			}
+
+			db = NewFactory(WithTag("service", "api"), WithPosition(p)).Wrap(
+				postgres.NewDB(&sdb, sqlparser.DefaultSQLParser()),
+			)
+		)
+
+		res, err := db.Exec(
+			context.Background(),
+			"INSERT INTO foo(bar) VALUES ($1)",
+			1,
+			&sql.Returning{Field: "id"},
+		)
+		assert.NoError(t, err)
+
+		id, err := res.LastInsertId()
+		assert.NoError(t, err)
+		assert.Equal(t, int64(2), id)
+
+		assert.Len(t, sdb.QueryRowQueries, 1)
+
+		stmt := sdb.QueryRowQueries[0].Query
+
+		assert.True(t, strings.HasSuffix(stmt, " RETURNING id"), stmt)
+		assert.Contains(t, stmt, "/*service='api'*/")
+	}
+}
+
+func TestReplicationRouting(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		master = static.DB{Queryer: static.Queryer{ExecResult: sql.StaticResult(1)}}
+		slave  = static.DB{
+			Queryer: static.Queryer{QueryScanner: &static.SingleCursor{}},
+		}
+
+		db = NewFactory(WithTag("service", "api"), WithPosition(Prepend)).Wrap(
+			replication.NewDB(
+				&master,
+				&slave,
+				sqlparser.NewCachedParser(sqlparser.DefaultSQLParser(), 16),
+			),
+		)
+	)
+
+	cur, err := db.Query(ctx, "SELECT 1")
+	assert.NoError(t, err)
+	assert.NoError(t, cur.Close())
+
+	_, err = db.Exec(ctx, "UPDATE foo SET bar = 1")
+	assert.NoError(t, err)
+
+	assert.Len(t, slave.QueryQueries, 1)
+	assert.Len(t, master.QueryQueries, 0)
+	assert.Len(t, master.ExecQueries, 1)
+	assert.Len(t, slave.ExecQueries, 0)
+}
@@ -0,0 +1,288 @@
+package tracing
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/upfluence/sql"
+	"github.com/upfluence/sql/sqlparser"
+)
+
+const (
+	execSpanName     = "sql.Exec"
+	queryRowSpanName = "sql.QueryRow"
+	querySpanName    = "sql.Query"
+	txSpanName       = "sql.Tx"
+)
+
+type Option func(*factory)
+
+func WithParser(p sqlparser.SQLParser) Option {
+	return func(f *factory) { f.p = p }
+}
+
+func NewFactory(t Tracer, opts ...Option) sql.MiddlewareFactory {
+	if t == nil {
+		t = NopTracer
+	}
+
+	f := factory{t: t, p: sqlparser.DefaultSQLParser()}
+
+	for _, opt := range opts {
+		opt(&f)
+	}
+
+	return &f
+}
+
+type factory struct {
+	t Tracer
+	p sqlparser.SQLParser
+}
+
+func (f *factory) Wrap(d sql.DB) sql.DB {
+	return &db{queryer: &queryer{q: d, f: f, driver: d.Driver()}, db: d}
+}
+
+type db struct {
+	*queryer
+
+	db sql.DB
+}
+
+func (d *db) Driver() string { return d.db.Driver() }
+
+func (d *db) Stats() []sql.NodeStats { return sql.NodeStatsOf("", d.db) }
+
+func (d *db) StatementCacheStats() sql.StatementCacheStats {
+	return sql.StatementCacheStatsOf(d.db)
+}
+
+func (d *db) Ping(ctx context.Context) []sql.NodeHealth {
+	return sql.PingNodes(ctx, "", d.db)
+}
+
+func (d *db) BeginTx(ctx context.Context, opts sql.TxOptions) (sql.Tx, error) {
+	ctx, span := d.f.t.Start(ctx, txSpanName, Attribute{SystemKey, d.driver})
+	ctx, rr := sql.WithRouteRecorder(ctx)
+
+	t, err := d.db.BeginTx(ctx, opts)
+
+	setRoute(span, rr)
+
+	if err != nil {
+		recordError(span, err)
+		span.End()
+
+		return nil, err
+	}
+
+	return &tx{
+		queryer: &queryer{q: t, f: d.f, driver: d.driver, parent: span},
+		tx:      t,
+		span:    span,
+	}, nil
+}
+
+type tx struct {
+	*queryer
+
+	tx   sql.Tx
+	span Span
+
+	once sync.Once
+}
+
+func (t *tx) end(outcome string, err error) error {
+	t.once.Do(func() {
+		t.span.SetAttributes(Attribute{TxOutcomeKey, outcome})
+		recordError(t.span, err)
+		t.span.End()
+	})
+
+	return err
+}
+
+func (t *tx) Commit() error   { return t.end("commit", t.tx.Commit()) }
+func (t *tx) Rollback() error { return t.end("rollback", t.tx.Rollback()) }
+
+func (t *tx) Savepoint(ctx context.Context, name string) error {
+	sp, ok := t.tx.(sql.Savepointer)
+
+	if !ok {
+		return sql.ErrSavepointNotSupported
+	}
+
+	return sp.Savepoint(ctx, name)
+}
+
+func (t *tx) ReleaseSavepoint(ctx context.Context, name string) error {
+	sp, ok := t.tx.(sql.Savepointer)
+
+	if !ok {
+		return sql.ErrSavepointNotSupported
+	}
+
+	return sp.ReleaseSavepoint(ctx, name)
+}
+
+func (t *tx) RollbackTo(ctx context.Context, name string) error {
+	sp, ok := t.tx.(sql.Savepointer)
+
+	if !ok {
+		return sql.ErrSavepointNotSupported
+	}
+
+	return sp.RollbackTo(ctx, name)
+}
+
+func recordError(span Span, err error) {
+	if err == nil {
+		return
+	}
+
+	span.RecordError(err)
+	span.SetAttributes(Attribute{ErrorTypeKey, string(sql.ClassifyError(err))})
+}
+
+func setRoute(span Span, rr *sql.RouteRecorder) {
+	if nodes := rr.Nodes(); len(nodes) > 0 {
+		span.SetAttributes(Attribute{RouteKey, strings.Join(nodes, "/")})
+	}
+}
+
+type queryer struct {
+	q sql.Queryer
+	f *factory
+
+	driver string
+	parent Span
+}
+
+func (q *queryer) start(ctx context.Context, name, stmt string) (context.Context, Span, *sql.RouteRecorder) {
+	if q.parent != nil {
+		ctx = q.f.t.ContextWithSpan(ctx, q.parent)
+	}
+
+	var (
+		tags  = sql.QueryTags(ctx)
+		attrs = make([]Attribute, 0, len(tags)+3)
+	)
+
+	attrs = append(
+		attrs,
+		Attribute{SystemKey, q.driver},
+		Attribute{StatementKey, stmt},
+		Attribute{OperationKey, q.f.p.GetStatementType(stmt).String()},
+	)
+
+	for _, t := range tags {
+		attrs = append(attrs, Attribute{TagKeyPrefix + t.Key, t.Value})
+	}
+
+	ctx, span := q.f.t.Start(ctx, name, attrs...)
+
+	ctx, rr := sql.WithRouteRecorder(ctx)
+
+	return ctx, span, rr
+}
+
+func (q *queryer) Exec(ctx context.Context, stmt string, vs ...interface{}) (sql.Result, error) {
+	ctx, span, rr := q.start(ctx, execSpanName, stmt)
+	defer span.End()
+
+	res, err := q.q.Exec(ctx, stmt, vs...)
+
+	setRoute(span, rr)
+	recordError(span, err)
+
+	if err == nil {
+		if n, err := res.RowsAffected(); err == nil {
+			span.SetAttributes(Attribute{RowsKey, n})
+		}
+	}
+
+	return res, err
+}
+
+func (q *queryer) QueryRow(ctx context.Context, stmt string, vs ...interface{}) sql.Scanner {
+	ctx, span, rr := q.start(ctx, queryRowSpanName, stmt)
+
+	sc := q.q.QueryRow(ctx, stmt, vs...)
+
+	setRoute(span, rr)
+
+	return &scanner{sc: sc, span: span}
+}
+
+func (q *queryer) Query(ctx context.Context, stmt string, vs ...interface{}) (sql.Cursor, error) {
+	ctx, span, rr := q.start(ctx, querySpanName, stmt)
+
+	cur, err := q.q.Query(ctx, stmt, vs...)
+
+	setRoute(span, rr)
+
+	if err != nil {
+		recordError(span, err)
+		span.End()
+
+		return nil, err
+	}
+
+	return &cursor{Cursor: cur, span: span}, nil
+}
+
+type scanner struct {
+	sc   sql.Scanner
+	span Span
+}
+
+func (sc *scanner) Scan(vs ...interface{}) error {
+	err := sc.sc.Scan(vs...)
+
+	if err == nil {
+		sc.span.SetAttributes(Attribute{RowsKey, int64(1)})
+	}
+
+	recordError(sc.span, err)
+	sc.span.End()
+
+	return err
+}
+
+type cursor struct {
+	sql.Cursor
+
+	span Span
+	rows int64
+
+	once sync.Once
+}
+
+func (c *cursor) Next() bool {
+	ok := c.Cursor.Next()
+
+	if ok {
+		c.rows++
+	}
+
+	return ok
+}
+
+func (c *cursor) Close() error {
+	err := c.Cursor.Close()
+
+	c.once.Do(func() {
+		if cerr := c.Cursor.Err(); cerr != nil {
+			recordError(c.span, cerr)
+		} else {
+			recordError(c.span, err)
+		}
+
+		c.span.SetAttributes(Attribute{RowsKey, c.rows})
+		c.span.End()
+	})
+
+	return err
+}
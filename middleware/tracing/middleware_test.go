@@ -0,0 +1,177 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/upfluence/sql"
+	"github.com/upfluence/sql/backend/replication"
+	"github.com/upfluence/sql/backend/static"
+	"github.com/upfluence/sql/sqlparser"
+)
+
+type parentKey struct{}
+
+type mockSpan struct {
+	name   string
+	parent *mockSpan
+	attrs  map[string]interface{}
+	errs   []error
+	ended  bool
+
+	endCount int
+}
+
+func (ms *mockSpan) SetAttributes(attrs ...Attribute) {
+	for _, a := range attrs {
+		ms.attrs[a.Key] = a.Value
+	}
+}
+
+func (ms *mockSpan) RecordError(err error) { ms.errs = append(ms.errs, err) }
+func (ms *mockSpan) End() {
+	ms.ended = true
+	ms.endCount++
+}
+
+type mockTracer struct {
+	spans []*mockSpan
+}
+
+func (mt *mockTracer) Start(ctx context.Context, n string, attrs ...Attribute) (context.Context, Span) {
+	s := &mockSpan{name: n, attrs: make(map[string]interface{})}
+	s.parent, _ = ctx.Value(parentKey{}).(*mockSpan)
+	s.SetAttributes(attrs...)
+
+	mt.spans = append(mt.spans, s)
+
+	return mt.ContextWithSpan(ctx, s), s
+}
+
+func (mt *mockTracer) ContextWithSpan(ctx context.Context, s Span) context.Context {
+	return context.WithValue(ctx, parentKey{}, s)
+}
+
+func TestQuery(t *testing.T) {
+	var (
+		ctx    = context.Background()
+		mt     mockTracer
+		master = static.DB{}
+		slave  = static.DB{
+			Queryer: static.Queryer{QueryScanner: &static.SingleCursor{}},
+		}
+
+		db = NewFactory(&mt).Wrap(
+			replication.NewDB(&master, &slave, sqlparser.DefaultSQLParser()),
+		)
+	)
+
+	cur, err := db.Query(ctx, "SELECT 1")
+	assert.NoError(t, err)
+
+	assert.Len(t, mt.spans, 1)
+	assert.False(t, mt.spans[0].ended)
+
+	for cur.Next() {
+		assert.NoError(t, cur.Scan())
+	}
+
+	assert.NoError(t, cur.Close())
+	assert.NoError(t, cur.Close())
+
+	s := mt.spans[0]
+
+	assert.Equal(t, 1, s.endCount)
+	assert.Equal(t, querySpanName, s.name)
+	assert.Equal(
+		t,
+		map[string]interface{}{
+			SystemKey:    "sqltest",
+			StatementKey: "SELECT 1",
+			OperationKey: "SELECT",
+			RouteKey:     "replica",
+			RowsKey:      int64(1),
+		},
+		s.attrs,
+	)
+}
+
+func TestTx(t *testing.T) {
+	var (
+		ctx = context.Background()
+		mt  mockTracer
+		sdb = static.DB{
+			Tx: &static.Tx{
+				Queryer: static.Queryer{
+					QueryRowScanner: static.Scanner{Err: sql.ErrNoRows},
+				},
+			},
+		}
+
+		db = NewFactory(&mt).Wrap(&sdb)
+	)
+
+	tx, err := db.BeginTx(ctx, sql.TxOptions{})
+	assert.NoError(t, err)
+
+	assert.Equal(t, sql.ErrNoRows, tx.QueryRow(ctx, "SELECT 1").Scan())
+	assert.NoError(t, tx.Rollback())
+
+	assert.Len(t, mt.spans, 2)
+
+	txSpan, qSpan := mt.spans[0], mt.spans[1]
+
+	assert.Equal(t, txSpanName, txSpan.name)
+	assert.True(t, txSpan.ended)
+	assert.Equal(t, "rollback", txSpan.attrs[TxOutcomeKey])
+
+	assert.Equal(t, queryRowSpanName, qSpan.name)
+	assert.Equal(t, txSpan, qSpan.parent)
+	assert.True(t, qSpan.ended)
+	assert.Equal(t, []error{sql.ErrNoRows}, qSpan.errs)
+	assert.Equal(t, "no_rows", qSpan.attrs[ErrorTypeKey])
+}
+
+func TestTxRollbackAfterCommit(t *testing.T) {
+	var (
+		ctx = context.Background()
+		mt  mockTracer
+		sdb = static.DB{Tx: &static.Tx{RollbackErr: sql.ErrTxDone}}
+
+		db = NewFactory(&mt).Wrap(&sdb)
+	)
+
+	tx, err := db.BeginTx(ctx, sql.TxOptions{})
+	assert.NoError(t, err)
+
+	assert.NoError(t, tx.Commit())
+	assert.Equal(t, sql.ErrTxDone, tx.Rollback())
+
+	assert.Len(t, mt.spans, 1)
+
+	s := mt.spans[0]
+
+	assert.Equal(t, 1, s.endCount)
+	assert.Equal(t, "commit", s.attrs[TxOutcomeKey])
+	assert.Empty(t, s.errs)
+}
+
+func TestQueryTags(t *testing.T) {
+	var (
+		mt  mockTracer
+		sdb = static.DB{
+			Queryer: static.Queryer{ExecResult: sql.StaticResult(1)},
+		}
+
+		db  = NewFactory(&mt).Wrap(&sdb)
+		ctx = sql.WithQueryTag(context.Background(), "route", "/users")
+	)
+
+	_, err := db.Exec(ctx, "UPDATE foo SET bar = 1")
+	assert.NoError(t, err)
+
+	assert.Len(t, mt.spans, 1)
+	assert.Equal(t, "/users", mt.spans[0].attrs["db.tag.route"])
+}
@@ -0,0 +1,59 @@
+package tracing
+
+import "context"
+
+const (
+	SystemKey    = "db.system"
+	StatementKey = "db.statement"
+	OperationKey = "db.operation"
+	RowsKey      = "db.rows"
+	RouteKey     = "db.route"
+	ErrorTypeKey = "error.type"
+	TxOutcomeKey = "db.tx.outcome"
+
+	// TagKeyPrefix prefixes the keys of the sql.QueryTag attached to the
+	// context of the query.
+	TagKeyPrefix = "db.tag."
+)
+
+type Attribute struct {
+	Key   string
+	Value interface{}
+}
+
+// Span mirrors the subset of the OpenTelemetry span API used by the
+// middleware.
+type Span interface {
+	SetAttributes(...Attribute)
+	RecordError(error)
+	End()
+}
+
+// Tracer mirrors the subset of the OpenTelemetry tracer API used by the
+// middleware, adapting an OpenTelemetry tracer only requires a thin wrapper.
+type Tracer interface {
+	Start(context.Context, string, ...Attribute) (context.Context, Span)
+
+	// ContextWithSpan returns a copy of the context in which the span is
+	// the current one, it is used to parent the queries executed within a
+	// transaction.
+	ContextWithSpan(context.Context, Span) context.Context
+}
+
+var NopTracer Tracer = nopTracer{}
+
+type nopTracer struct{}
+
+func (nopTracer) Start(ctx context.Context, _ string, _ ...Attribute) (context.Context, Span) {
+	return ctx, nopSpan{}
+}
+
+func (nopTracer) ContextWithSpan(ctx context.Context, _ Span) context.Context {
+	return ctx
+}
+
+type nopSpan struct{}
+
+func (nopSpan) SetAttributes(...Attribute) {}
+func (nopSpan) RecordError(error)          {}
+func (nopSpan) End()                       {}
@@ -0,0 +1,62 @@
+package sql
+
+import (
+	"context"
+	"iter"
+)
+
+// Rows iterates over the rows of the cursor, each one being decoded by fn.
+// The iteration stops at the first error, which is yielded along with the
+// zero value of T. The cursor is closed once the iteration is done, so the
+// sequence can only be ranged over once.
+func Rows[T any](c Cursor, fn func(Scanner) (T, error)) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+
+		defer c.Close()
+
+		for c.Next() {
+			v, err := fn(c)
+
+			if err != nil {
+				yield(zero, err)
+				return
+			}
+
+			if !yield(v, nil) {
+				return
+			}
+		}
+
+		if err := c.Err(); err != nil {
+			yield(zero, err)
+		}
+	}
+}
+
+// QueryAll executes the query and decodes all the returned rows with fn.
+func QueryAll[T any](ctx context.Context, q Queryer, fn func(Scanner) (T, error), stmt string, vs ...interface{}) ([]T, error) {
+	cur, err := q.Query(ctx, stmt, vs...)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var res []T
+
+	for v, err := range Rows(cur, fn) {
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, v)
+	}
+
+	return res, nil
+}
+
+// QueryOne executes the query and decodes its first row with fn, ErrNoRows
+// is returned when the query matches no row.
+func QueryOne[T any](ctx context.Context, q Queryer, fn func(Scanner) (T, error), stmt string, vs ...interface{}) (T, error) {
+	return fn(q.QueryRow(ctx, stmt, vs...))
+}
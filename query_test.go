@@ -0,0 +1,167 @@
+package sql_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/upfluence/sql"
+	"github.com/upfluence/sql/backend/static"
+)
+
+type trackingCursor struct {
+	sql.Cursor
+
+	closed bool
+}
+
+func (tc *trackingCursor) Close() error {
+	tc.closed = true
+	return tc.Cursor.Close()
+}
+
+func int64Cursor(err error, vs ...int64) *trackingCursor {
+	var scs []static.Scanner
+
+	for _, v := range vs {
+		scs = append(scs, static.Scanner{Args: []static.ScanArg{static.Int64Arg(v)}})
+	}
+
+	return &trackingCursor{
+		Cursor: &static.MultipleCursor{Scanners: scs, ReturnedErr: err},
+	}
+}
+
+func scanInt64(sc sql.Scanner) (int64, error) {
+	var v int64
+
+	err := sc.Scan(&v)
+
+	return v, err
+}
+
+func TestRows(t *testing.T) {
+	var (
+		errCursor = errors.New("cursor")
+		errScan   = errors.New("scan")
+	)
+
+	for _, tt := range []struct {
+		name string
+		cur  *trackingCursor
+		fn   func(sql.Scanner) (int64, error)
+		max  int
+
+		want    []int64
+		wantErr error
+	}{
+		{
+			name: "all rows",
+			cur:  int64Cursor(nil, 1, 2, 3),
+			fn:   scanInt64,
+			want: []int64{1, 2, 3},
+		},
+		{
+			name: "break",
+			cur:  int64Cursor(nil, 1, 2, 3),
+			fn:   scanInt64,
+			max:  2,
+			want: []int64{1, 2},
+		},
+		{
+			name: "scan error",
+			cur:  int64Cursor(nil, 1, 2, 3),
+			fn: func(sc sql.Scanner) (int64, error) {
+				v, _ := scanInt64(sc)
+
+				if v == 2 {
+					return 0, errScan
+				}
+
+				return v, nil
+			},
+			want:    []int64{1},
+			wantErr: errScan,
+		},
+		{
+			name:    "cursor error",
+			cur:     int64Cursor(errCursor, 1),
+			fn:      scanInt64,
+			want:    []int64{1},
+			wantErr: errCursor,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				res []int64
+				err error
+			)
+
+			for v, verr := range sql.Rows(tt.cur, tt.fn) {
+				if verr != nil {
+					err = verr
+					continue
+				}
+
+				res = append(res, v)
+
+				if len(res) == tt.max {
+					break
+				}
+			}
+
+			assert.Equal(t, tt.want, res)
+			assert.Equal(t, tt.wantErr, err)
+			assert.True(t, tt.cur.closed)
+		})
+	}
+}
+
+func TestQueryAll(t *testing.T) {
+	var (
+		ctx = context.Background()
+		cur = int64Cursor(nil, 4, 2)
+		db  = static.DB{Queryer: static.Queryer{QueryScanner: cur}}
+	)
+
+	res, err := sql.QueryAll(ctx, &db, scanInt64, "SELECT id FROM foo WHERE bar = $1", 1)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{4, 2}, res)
+	assert.True(t, cur.closed)
+
+	db.QueryQueries[0].Assert(t, "SELECT id FROM foo WHERE bar = $1", 1)
+
+	db.QueryErr = sql.ErrConnDone
+
+	res, err = sql.QueryAll(ctx, &db, scanInt64, "SELECT id FROM foo")
+
+	assert.Nil(t, res)
+	assert.Equal(t, sql.ErrConnDone, err)
+}
+
+func TestQueryOne(t *testing.T) {
+	var (
+		ctx = context.Background()
+		db  = static.DB{
+			Queryer: static.Queryer{
+				QueryRowScanner: static.Scanner{
+					Args: []static.ScanArg{static.Int64Arg(7)},
+				},
+			},
+		}
+	)
+
+	v, err := sql.QueryOne(ctx, &db, scanInt64, "SELECT id FROM foo")
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), v)
+
+	db.QueryRowScanner = static.Scanner{Err: sql.ErrNoRows}
+
+	_, err = sql.QueryOne(ctx, &db, scanInt64, "SELECT id FROM foo")
+
+	assert.Equal(t, sql.ErrNoRows, err)
+}
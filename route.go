@@ -0,0 +1,42 @@
+package sql
+
+import (
+	"context"
+	"sync"
+)
+
+type routeRecorderKey struct{}
+
+// RouteRecorder collects the nodes traversed by a query when it is routed by
+// the replication and balancer backends.
+type RouteRecorder struct {
+	mu    sync.Mutex
+	nodes []string
+}
+
+func (rr *RouteRecorder) Nodes() []string {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	return append([]string(nil), rr.nodes...)
+}
+
+func WithRouteRecorder(ctx context.Context) (context.Context, *RouteRecorder) {
+	var rr RouteRecorder
+
+	return context.WithValue(ctx, routeRecorderKey{}, &rr), &rr
+}
+
+// RecordRoute appends the node to the recorder carried by the context if
+// any.
+func RecordRoute(ctx context.Context, node string) {
+	rr, ok := ctx.Value(routeRecorderKey{}).(*RouteRecorder)
+
+	if !ok {
+		return
+	}
+
+	rr.mu.Lock()
+	rr.nodes = append(rr.nodes, node)
+	rr.mu.Unlock()
+}
@@ -0,0 +1,76 @@
+package sql
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/upfluence/errors"
+)
+
+var ErrInvalidSessionToken = errors.New("invalid session token")
+
+type sessionKey struct{}
+
+// Session tracks the last write issued on behalf of a caller so the
+// replication backend can route its subsequent reads to the master until
+// the replicas caught up with it (read-your-writes).
+type Session struct {
+	mu        sync.Mutex
+	lastWrite time.Time
+}
+
+func NewSession() *Session { return &Session{} }
+
+// ParseSessionToken restores a session serialized with Token, it allows to
+// carry the guarantee across requests.
+func ParseSessionToken(tok string) (*Session, error) {
+	if tok == "" {
+		return NewSession(), nil
+	}
+
+	ns, err := strconv.ParseInt(tok, 36, 64)
+
+	if err != nil {
+		return nil, ErrInvalidSessionToken
+	}
+
+	return &Session{lastWrite: time.Unix(0, ns)}, nil
+}
+
+func (s *Session) Token() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.lastWrite.IsZero() {
+		return ""
+	}
+
+	return strconv.FormatInt(s.lastWrite.UnixNano(), 36)
+}
+
+func (s *Session) MarkWrite(t time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if t.After(s.lastWrite) {
+		s.lastWrite = t
+	}
+}
+
+func (s *Session) LastWrite() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.lastWrite
+}
+
+func WithSession(ctx context.Context, s *Session) context.Context {
+	return context.WithValue(ctx, sessionKey{}, s)
+}
+
+func SessionFromContext(ctx context.Context) (*Session, bool) {
+	s, ok := ctx.Value(sessionKey{}).(*Session)
+	return s, ok
+}
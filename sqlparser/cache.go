@@ -0,0 +1,121 @@
+package sqlparser
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+)
+
+type cacheEntry struct {
+	stmt string
+	st   Statement
+}
+
+type cachedParser struct {
+	p    SQLParser
+	size int
+
+	mu      sync.Mutex
+	ll      *list.List
+	entries map[string]*list.Element
+}
+
+type statementParser struct {
+	SQLParser
+}
+
+func (sp statementParser) ParseStatement(stmt string) Statement {
+	return Parse(sp.SQLParser, stmt)
+}
+
+// NewCachedParser memoizes the descriptions built by p for the size most
+// recently parsed statements, a size lower or equal to zero disables the
+// caching.
+func NewCachedParser(p SQLParser, size int) StatementParser {
+	if size <= 0 {
+		if sp, ok := p.(StatementParser); ok {
+			return sp
+		}
+
+		return statementParser{SQLParser: p}
+	}
+
+	return &cachedParser{
+		p:       p,
+		size:    size,
+		ll:      list.New(),
+		entries: make(map[string]*list.Element, size),
+	}
+}
+
+func (cp *cachedParser) GetStatementType(stmt string) StmtType {
+	return cp.ParseStatement(stmt).Type
+}
+
+// cacheKey strips the comments surrounding the statement, such as the ones
+// added by sqlcommenter: they do not change its description and would
+// otherwise make every statement text unique.
+func cacheKey(stmt string) string {
+	for {
+		stmt = strings.TrimSpace(stmt)
+
+		switch {
+		case strings.HasPrefix(stmt, "/*"):
+			t := NewLexer(stmt).Next()
+
+			if t.Kind != TokenComment {
+				return stmt
+			}
+
+			stmt = stmt[len(t.Value):]
+		case strings.HasSuffix(stmt, "*/"):
+			i := strings.LastIndex(stmt, "/*")
+
+			if i < 0 {
+				return stmt
+			}
+
+			if t := NewLexer(stmt[i:]).Next(); len(t.Value) != len(stmt)-i {
+				return stmt
+			}
+
+			stmt = stmt[:i]
+		default:
+			return stmt
+		}
+	}
+}
+
+func (cp *cachedParser) ParseStatement(stmt string) Statement {
+	stmt = cacheKey(stmt)
+
+	cp.mu.Lock()
+
+	if e, ok := cp.entries[stmt]; ok {
+		cp.ll.MoveToFront(e)
+		cp.mu.Unlock()
+
+		return e.Value.(*cacheEntry).st
+	}
+
+	cp.mu.Unlock()
+
+	st := Parse(cp.p, stmt)
+
+	cp.mu.Lock()
+	defer cp.mu.Unlock()
+
+	if _, ok := cp.entries[stmt]; ok {
+		return st
+	}
+
+	cp.entries[stmt] = cp.ll.PushFront(&cacheEntry{stmt: stmt, st: st})
+
+	if cp.ll.Len() > cp.size {
+		e := cp.ll.Back()
+		cp.ll.Remove(e)
+		delete(cp.entries, e.Value.(*cacheEntry).stmt)
+	}
+
+	return st
+}
@@ -0,0 +1,309 @@
+package sqlparser
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type TokenKind uint8
+
+const (
+	TokenEOF TokenKind = iota
+	TokenWhitespace
+	TokenComment
+	TokenWord
+	TokenQuotedIdentifier
+	TokenString
+	TokenNumber
+	TokenPlaceholder
+	TokenPunctuation
+)
+
+// Token is a lexical unit of a statement, Value holds its raw text as found
+// in the statement starting at the byte offset Pos.
+type Token struct {
+	Kind  TokenKind
+	Value string
+	Pos   int
+}
+
+// PlaceholderIndex returns n for a $n placeholder.
+func (t Token) PlaceholderIndex() (int, bool) {
+	if t.Kind != TokenPlaceholder || !strings.HasPrefix(t.Value, "$") {
+		return 0, false
+	}
+
+	n, err := strconv.Atoi(t.Value[1:])
+
+	return n, err == nil
+}
+
+// Lexer splits a statement into tokens. It understands the quoting rules
+// shared by postgres, sqlite and mysql: single quoted strings (E prefixed
+// ones allowing backslash escapes), dollar quoted strings, double quoted and
+// backquoted identifiers, line and nested block comments. The backslash
+// escapes mysql allows in every string require WithBackslashEscapes.
+type Lexer struct {
+	stmt string
+	pos  int
+
+	backslash bool
+}
+
+type LexerOption func(*Lexer)
+
+// WithBackslashEscapes lets a backslash escape the next character of the
+// single and double quoted sections, as mysql does unless its sql_mode holds
+// NO_BACKSLASH_ESCAPES.
+func WithBackslashEscapes() LexerOption {
+	return func(l *Lexer) { l.backslash = true }
+}
+
+func NewLexer(stmt string, opts ...LexerOption) *Lexer {
+	l := Lexer{stmt: stmt}
+
+	for _, opt := range opts {
+		opt(&l)
+	}
+
+	return &l
+}
+
+// Tokenize returns every token of the statement.
+func Tokenize(stmt string, opts ...LexerOption) []Token {
+	var (
+		l  = NewLexer(stmt, opts...)
+		ts []Token
+	)
+
+	for t := l.Next(); t.Kind != TokenEOF; t = l.Next() {
+		ts = append(ts, t)
+	}
+
+	return ts
+}
+
+func (l *Lexer) peek(i int) byte {
+	if l.pos+i >= len(l.stmt) {
+		return 0
+	}
+
+	return l.stmt[l.pos+i]
+}
+
+// Next returns the next token of the statement, a token of kind TokenEOF is
+// returned once the statement is consumed.
+func (l *Lexer) Next() Token {
+	if l.pos >= len(l.stmt) {
+		return Token{Kind: TokenEOF, Pos: l.pos}
+	}
+
+	var (
+		start = l.pos
+		kind  TokenKind
+	)
+
+	switch c := l.peek(0); {
+	case c == '-' && l.peek(1) == '-':
+		kind = TokenComment
+		l.skipLineComment()
+	case c == '/' && l.peek(1) == '*':
+		kind = TokenComment
+		l.skipBlockComment()
+	case c == '\'':
+		kind = TokenString
+		l.skipQuoted('\'', l.backslash)
+	case (c == 'e' || c == 'E') && l.peek(1) == '\'':
+		kind = TokenString
+		l.pos++
+		l.skipQuoted('\'', true)
+	case c == '"' || c == '`':
+		kind = TokenQuotedIdentifier
+		l.skipQuoted(c, c == '"' && l.backslash)
+	case c == '$' && isDigit(l.peek(1)):
+		kind = TokenPlaceholder
+		l.pos++
+		l.skipWhile(isDigit)
+	case c == '$' && l.skipDollarQuoted():
+		kind = TokenString
+	case c == '?':
+		kind = TokenPlaceholder
+		l.pos++
+		l.skipWhile(isDigit)
+	case isDigit(c) || (c == '.' && isDigit(l.peek(1))):
+		kind = TokenNumber
+		l.skipNumber()
+	case isIdentifierStart(l.rune()):
+		kind = TokenWord
+		l.skipWord()
+	case isSpace(l.rune()):
+		kind = TokenWhitespace
+		l.skipRunes(isSpace)
+	default:
+		kind = TokenPunctuation
+		_, n := utf8.DecodeRuneInString(l.stmt[l.pos:])
+		l.pos += n
+	}
+
+	return Token{Kind: kind, Value: l.stmt[start:l.pos], Pos: start}
+}
+
+func (l *Lexer) rune() rune {
+	r, _ := utf8.DecodeRuneInString(l.stmt[l.pos:])
+	return r
+}
+
+func (l *Lexer) skipWhile(fn func(byte) bool) {
+	for l.pos < len(l.stmt) && fn(l.stmt[l.pos]) {
+		l.pos++
+	}
+}
+
+func (l *Lexer) skipRunes(fn func(rune) bool) {
+	for l.pos < len(l.stmt) {
+		r, n := utf8.DecodeRuneInString(l.stmt[l.pos:])
+
+		if !fn(r) {
+			return
+		}
+
+		l.pos += n
+	}
+}
+
+func (l *Lexer) skipLineComment() {
+	if i := strings.IndexByte(l.stmt[l.pos:], '\n'); i > -1 {
+		l.pos += i + 1
+	} else {
+		l.pos = len(l.stmt)
+	}
+}
+
+func (l *Lexer) skipBlockComment() {
+	var depth int
+
+	for l.pos < len(l.stmt) {
+		switch {
+		case l.peek(0) == '/' && l.peek(1) == '*':
+			depth++
+			l.pos += 2
+		case l.peek(0) == '*' && l.peek(1) == '/':
+			depth--
+			l.pos += 2
+
+			if depth == 0 {
+				return
+			}
+		default:
+			l.pos++
+		}
+	}
+}
+
+// skipQuoted consumes a quoted section, the quote character is escaped by
+// doubling it or, when backslash is set, by prefixing it with a backslash.
+func (l *Lexer) skipQuoted(q byte, backslash bool) {
+	l.pos++
+
+	for l.pos < len(l.stmt) {
+		switch c := l.stmt[l.pos]; {
+		case backslash && c == '\\':
+			l.pos += 2
+		case c == q && l.peek(1) == q:
+			l.pos += 2
+		case c == q:
+			l.pos++
+			return
+		default:
+			l.pos++
+		}
+	}
+
+	l.pos = len(l.stmt)
+}
+
+// skipDollarQuoted consumes a $tag$...$tag$ string, it returns false and
+// leaves the lexer untouched when the $ does not open such a string.
+func (l *Lexer) skipDollarQuoted() bool {
+	end := strings.IndexByte(l.stmt[l.pos+1:], '$')
+
+	if end == -1 {
+		return false
+	}
+
+	tag := l.stmt[l.pos : l.pos+end+2]
+
+	for i, r := range tag[1 : len(tag)-1] {
+		if !isIdentifierStart(r) && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+
+	if i := strings.Index(l.stmt[l.pos+len(tag):], tag); i > -1 {
+		l.pos += len(tag) + i + len(tag)
+	} else {
+		l.pos = len(l.stmt)
+	}
+
+	return true
+}
+
+func (l *Lexer) skipNumber() {
+	l.skipWhile(isDigit)
+
+	if l.peek(0) == '.' {
+		l.pos++
+		l.skipWhile(isDigit)
+	}
+
+	if c := l.peek(0); c == 'e' || c == 'E' {
+		switch n := l.peek(1); {
+		case isDigit(n):
+			l.pos++
+		case (n == '+' || n == '-') && isDigit(l.peek(2)):
+			l.pos += 2
+		default:
+			return
+		}
+
+		l.skipWhile(isDigit)
+	}
+}
+
+func (l *Lexer) skipWord() {
+	l.skipRunes(func(r rune) bool {
+		return isIdentifierStart(r) || unicode.IsDigit(r) || r == '$'
+	})
+}
+
+func isDigit(c byte) bool { return c >= '0' && c <= '9' }
+
+func isSpace(r rune) bool { return unicode.IsSpace(r) }
+
+func isIdentifierStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+// RewritePlaceholders replaces the $n placeholders found outside of the
+// string literals, quoted identifiers and comments of the statement by the
+// value returned by fn.
+func RewritePlaceholders(stmt string, fn func(int) string, opts ...LexerOption) string {
+	var (
+		b strings.Builder
+		l = NewLexer(stmt, opts...)
+	)
+
+	b.Grow(len(stmt))
+
+	for t := l.Next(); t.Kind != TokenEOF; t = l.Next() {
+		if n, ok := t.PlaceholderIndex(); ok {
+			b.WriteString(fn(n))
+		} else {
+			b.WriteString(t.Value)
+		}
+	}
+
+	return b.String()
+}
@@ -0,0 +1,146 @@
+package sqlparser
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name string
+		stmt string
+		want []TokenKind
+	}{
+		{
+			name: "select",
+			stmt: "SELECT a, 1.5e3 FROM t",
+			want: []TokenKind{
+				TokenWord, TokenWhitespace, TokenWord, TokenPunctuation,
+				TokenWhitespace, TokenNumber, TokenWhitespace, TokenWord,
+				TokenWhitespace, TokenWord,
+			},
+		},
+		{
+			name: "quotes",
+			stmt: `'it''s' E'\'' "a""b" ` + "`c`",
+			want: []TokenKind{
+				TokenString, TokenWhitespace, TokenString, TokenWhitespace,
+				TokenQuotedIdentifier, TokenWhitespace, TokenQuotedIdentifier,
+			},
+		},
+		{
+			name: "dollar quoting",
+			stmt: "$$ $1 $$ $fn$ $$ $fn$ $1",
+			want: []TokenKind{
+				TokenString, TokenWhitespace, TokenString, TokenWhitespace,
+				TokenPlaceholder,
+			},
+		},
+		{
+			name: "comments",
+			stmt: "-- $1\n/* /* $2 */ $3 */?",
+			want: []TokenKind{TokenComment, TokenComment, TokenPlaceholder},
+		},
+		{
+			name: "unterminated",
+			stmt: "'foo",
+			want: []TokenKind{TokenString},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				ts    = Tokenize(tt.stmt)
+				kinds = make([]TokenKind, len(ts))
+				raw   string
+			)
+
+			for i, t := range ts {
+				kinds[i] = t.Kind
+				raw += t.Value
+			}
+
+			if !reflect.DeepEqual(kinds, tt.want) {
+				t.Errorf("Tokenize() = %v, want %v", kinds, tt.want)
+			}
+
+			if raw != tt.stmt {
+				t.Errorf("Tokenize() values = %q, want %q", raw, tt.stmt)
+			}
+		})
+	}
+}
+
+func TestRewritePlaceholders(t *testing.T) {
+	tests := []struct {
+		name string
+		stmt string
+		want string
+	}{
+		{
+			name: "plain",
+			stmt: "SELECT * FROM t WHERE a = $1 AND b = $12",
+			want: "SELECT * FROM t WHERE a = :1 AND b = :12",
+		},
+		{
+			name: "literals",
+			stmt: "SELECT '$1', $2, $tag$ $3 $tag$, \"$4\" -- $5",
+			want: "SELECT '$1', :2, $tag$ $3 $tag$, \"$4\" -- $5",
+		},
+		{
+			name: "repeated",
+			stmt: "$1 + $1::int",
+			want: ":1 + :1::int",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := RewritePlaceholders(
+				tt.stmt,
+				func(n int) string { return ":" + strconv.Itoa(n) },
+			)
+
+			if got != tt.want {
+				t.Errorf("RewritePlaceholders() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRewritePlaceholdersBackslashEscapes(t *testing.T) {
+	for _, tt := range []struct {
+		stmt string
+		opts []LexerOption
+		want string
+	}{
+		{stmt: `'it\'s $1', $2`, want: `'it\'s :1', $2`},
+		{
+			stmt: `'it\'s $1', $2`,
+			opts: []LexerOption{WithBackslashEscapes()},
+			want: `'it\'s $1', :2`,
+		},
+		{
+			stmt: `"a\" $1", $2`,
+			opts: []LexerOption{WithBackslashEscapes()},
+			want: `"a\" $1", :2`,
+		},
+		{
+			stmt: "`a\\` $1",
+			opts: []LexerOption{WithBackslashEscapes()},
+			want: "`a\\` :1",
+		},
+	} {
+		got := RewritePlaceholders(
+			tt.stmt,
+			func(n int) string { return ":" + strconv.Itoa(n) },
+			tt.opts...,
+		)
+
+		if got != tt.want {
+			t.Errorf("RewritePlaceholders(%q) = %q, want %q", tt.stmt, got, tt.want)
+		}
+	}
+}
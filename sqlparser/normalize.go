@@ -0,0 +1,124 @@
+package sqlparser
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+)
+
+const normalizedLiteral = "?"
+
+// Normalize returns the shape of the statement: comments are removed,
+// whitespaces are made canonical, literals and placeholders are replaced by
+// ?, IN lists are collapsed to a single element and only the first row of a
+// multi-row VALUES clause is kept.
+func Normalize(stmt string) string {
+	var (
+		p = parser{ts: significantTokens(stmt)}
+		b strings.Builder
+
+		prev    string
+		rowsEnd = -1
+	)
+
+	write := func(v string) {
+		if b.Len() > 0 && needsSpace(prev, v) {
+			b.WriteByte(' ')
+		}
+
+		b.WriteString(v)
+		prev = v
+	}
+
+	for i := 0; i < len(p.ts); {
+		if i == rowsEnd {
+			for p.isPunct(i, ",") && p.isPunct(i+1, "(") {
+				i = p.skipParens(i + 1)
+			}
+
+			if i >= len(p.ts) {
+				break
+			}
+		}
+
+		switch kw := p.word(i); {
+		case kw == "in" && p.isLiteralList(i+1):
+			write(p.ts[i].Value)
+			write("(")
+			write(normalizedLiteral)
+			write(")")
+
+			i = p.skipParens(i + 1)
+
+			continue
+		case kw == "values" && p.isPunct(i+1, "("):
+			rowsEnd = p.skipParens(i + 1)
+		}
+
+		write(normalizeToken(p.ts[i]))
+		i++
+	}
+
+	return b.String()
+}
+
+// Fingerprint returns a hash of the normalized statement, statements
+// sharing the same shape share the same fingerprint regardless of the case
+// of their keywords.
+func Fingerprint(stmt string) string {
+	h := fnv.New64a()
+	h.Write([]byte(strings.ToLower(Normalize(stmt))))
+
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+func normalizeToken(t Token) string {
+	switch t.Kind {
+	case TokenString, TokenNumber, TokenPlaceholder:
+		return normalizedLiteral
+	}
+
+	return t.Value
+}
+
+// isLiteralList reports whether the parenthesis opened at i only holds
+// comma separated literals or placeholders.
+func (p *parser) isLiteralList(i int) bool {
+	if !p.isPunct(i, "(") {
+		return false
+	}
+
+	end := p.skipParens(i)
+
+	if end-i < 3 || !p.isPunct(end-1, ")") {
+		return false
+	}
+
+	for j := i + 1; j < end-1; j++ {
+		switch p.ts[j].Kind {
+		case TokenString, TokenNumber, TokenPlaceholder:
+		case TokenPunctuation:
+			if p.ts[j].Value != "," {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+
+	return true
+}
+
+func needsSpace(prev, cur string) bool {
+	switch prev {
+	case "(", ".", ":":
+		return false
+	}
+
+	switch cur {
+	case ")", ",", ".", ":":
+		return false
+	}
+
+	return true
+}
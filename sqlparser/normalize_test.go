@@ -0,0 +1,66 @@
+package sqlparser
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		stmt string
+		want string
+	}{
+		{
+			name: "literals",
+			stmt: "SELECT *\n  FROM foo -- comment\n WHERE a = 'bar' AND b = 12.5 AND c = $1",
+			want: "SELECT * FROM foo WHERE a = ? AND b = ? AND c = ?",
+		},
+		{
+			name: "in list",
+			stmt: "SELECT * FROM foo WHERE id IN ($1, $2, $3) AND x IN (SELECT y FROM bar)",
+			want: "SELECT * FROM foo WHERE id IN (?) AND x IN (SELECT y FROM bar)",
+		},
+		{
+			name: "multi row insert",
+			stmt: "INSERT INTO foo(a, b) VALUES ($1, $2), ($3, $4), ($5, $6) RETURNING id",
+			want: "INSERT INTO foo (a, b) VALUES (?, ?) RETURNING id",
+		},
+		{
+			name: "qualified names and casts",
+			stmt: "SELECT f.a::int FROM public.foo f /* c */",
+			want: "SELECT f.a::int FROM public.foo f",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Normalize(tt.stmt); got != tt.want {
+				t.Errorf("Normalize() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	for _, tt := range []struct {
+		a, b string
+		same bool
+	}{
+		{
+			a:    "INSERT INTO foo(a) VALUES ($1)",
+			b:    "INSERT INTO foo(a) VALUES ($1), ($2), ($3)",
+			same: true,
+		},
+		{
+			a:    "SELECT * FROM foo WHERE id IN ($1)",
+			b:    "select *   from foo where id in ($1, $2)",
+			same: true,
+		},
+		{
+			a: "SELECT * FROM foo",
+			b: "SELECT * FROM bar",
+		},
+	} {
+		if same := Fingerprint(tt.a) == Fingerprint(tt.b); same != tt.same {
+			t.Errorf("Fingerprint(%q) == Fingerprint(%q) = %v, want %v", tt.a, tt.b, same, tt.same)
+		}
+	}
+}
@@ -1,10 +1,5 @@
 package sqlparser
 
-import (
-	"strings"
-	"unicode"
-)
-
 type StmtType uint8
 
 const (
@@ -13,8 +8,32 @@ const (
 	StmtUpdate
 	StmtDelete
 	StmtUnknown
+	StmtDDL
+	StmtTxControl
+	StmtUtility
 )
 
+func (t StmtType) String() string {
+	switch t {
+	case StmtSelect:
+		return "SELECT"
+	case StmtInsert:
+		return "INSERT"
+	case StmtUpdate:
+		return "UPDATE"
+	case StmtDelete:
+		return "DELETE"
+	case StmtDDL:
+		return "DDL"
+	case StmtTxControl:
+		return "TRANSACTION"
+	case StmtUtility:
+		return "UTILITY"
+	}
+
+	return "UNKNOWN"
+}
+
 type SQLParser interface {
 	GetStatementType(string) StmtType
 }
@@ -32,25 +51,9 @@ func DefaultSQLParser() SQLParser { return sqlParser{} }
 type sqlParser struct{}
 
 func (sqlParser) GetStatementType(stmt string) StmtType {
-	firstWord := strings.TrimLeftFunc(
-		stmt,
-		func(r rune) bool { return !unicode.IsLetter(r) },
-	)
-
-	if end := strings.IndexFunc(firstWord, unicode.IsSpace); end != -1 {
-		firstWord = firstWord[:end]
-	}
-
-	switch strings.ToLower(firstWord) {
-	case "select":
-		return StmtSelect
-	case "insert":
-		return StmtInsert
-	case "update":
-		return StmtUpdate
-	case "delete":
-		return StmtDelete
-	}
+	return parseStatement(stmt).Type
+}
 
-	return StmtUnknown
+func (sqlParser) ParseStatement(stmt string) Statement {
+	return parseStatement(stmt)
 }
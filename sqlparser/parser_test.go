@@ -39,6 +39,24 @@ func Test_sqlParser_GetStatementType(t *testing.T) {
 			stmt: "\n\nDELETE FROM xx",
 			want: StmtDelete,
 		},
+
+		{
+			name: "with select",
+			stmt: "WITH x AS (SELECT 1) SELECT * FROM x",
+			want: StmtSelect,
+		},
+
+		{
+			name: "with insert",
+			stmt: "WITH x AS (SELECT 1) INSERT INTO foo SELECT * FROM x",
+			want: StmtInsert,
+		},
+
+		{
+			name: "ddl",
+			stmt: "ALTER TABLE foo ADD COLUMN bar TEXT",
+			want: StmtDDL,
+		},
 	}
 
 	for _, tt := range tests {
@@ -70,3 +88,21 @@ func TestIsDML(t *testing.T) {
 		})
 	}
 }
+
+func TestStmtTypeValues(t *testing.T) {
+	// The values are part of the API, the new types are appended.
+	for want, st := range []StmtType{
+		StmtSelect,
+		StmtInsert,
+		StmtUpdate,
+		StmtDelete,
+		StmtUnknown,
+		StmtDDL,
+		StmtTxControl,
+		StmtUtility,
+	} {
+		if int(st) != want {
+			t.Errorf("%v = %d, want %d", st, st, want)
+		}
+	}
+}
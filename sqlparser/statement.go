@@ -0,0 +1,577 @@
+package sqlparser
+
+import "strings"
+
+type LockingClause uint8
+
+const (
+	NoLocking LockingClause = iota
+	ForUpdate
+	ForNoKeyUpdate
+	ForShare
+	ForKeyShare
+)
+
+// Statement describes a parsed statement.
+type Statement struct {
+	Type StmtType
+
+	// ReadOnly reports whether the statement can be served by a replica:
+	// it neither writes, locks rows nor calls a function with side effects.
+	ReadOnly bool
+	Locking  LockingClause
+
+	// Tables lists the tables referenced by the statement in order of
+	// appearance, the names of the common table expressions are excluded.
+	// The slice is shared and must not be modified.
+	Tables []string
+}
+
+// StatementParser is implemented by the parsers able to describe a
+// statement beyond its type.
+type StatementParser interface {
+	SQLParser
+
+	ParseStatement(string) Statement
+}
+
+// Parse describes the statement with p, when p is not a StatementParser the
+// description is derived from the statement type only.
+func Parse(p SQLParser, stmt string) Statement {
+	if sp, ok := p.(StatementParser); ok {
+		return sp.ParseStatement(stmt)
+	}
+
+	t := p.GetStatementType(stmt)
+
+	return Statement{Type: t, ReadOnly: !IsDML(t)}
+}
+
+var (
+	writeKeywords = map[string]StmtType{
+		"insert":  StmtInsert,
+		"replace": StmtInsert,
+		"update":  StmtUpdate,
+		"delete":  StmtDelete,
+		"merge":   StmtUnknown,
+	}
+
+	statementKeywords = map[string]StmtType{
+		"select": StmtSelect,
+		"values": StmtSelect,
+		"table":  StmtSelect,
+
+		"create":   StmtDDL,
+		"alter":    StmtDDL,
+		"drop":     StmtDDL,
+		"truncate": StmtDDL,
+		"comment":  StmtDDL,
+		"grant":    StmtDDL,
+		"revoke":   StmtDDL,
+		"rename":   StmtDDL,
+
+		"begin":     StmtTxControl,
+		"start":     StmtTxControl,
+		"commit":    StmtTxControl,
+		"end":       StmtTxControl,
+		"rollback":  StmtTxControl,
+		"abort":     StmtTxControl,
+		"savepoint": StmtTxControl,
+		"release":   StmtTxControl,
+
+		"explain":    StmtUtility,
+		"show":       StmtUtility,
+		"describe":   StmtUtility,
+		"desc":       StmtUtility,
+		"set":        StmtUtility,
+		"reset":      StmtUtility,
+		"analyze":    StmtUtility,
+		"vacuum":     StmtUtility,
+		"pragma":     StmtUtility,
+		"use":        StmtUtility,
+		"lock":       StmtUtility,
+		"listen":     StmtUtility,
+		"unlisten":   StmtUtility,
+		"notify":     StmtUtility,
+		"copy":       StmtUtility,
+		"do":         StmtUtility,
+		"call":       StmtUtility,
+		"discard":    StmtUtility,
+		"checkpoint": StmtUtility,
+	}
+
+	readOnlyUtilities = map[string]struct{}{
+		"show":     {},
+		"describe": {},
+		"desc":     {},
+	}
+
+	volatileFunctions = map[string]struct{}{
+		"nextval":            {},
+		"setval":             {},
+		"currval":            {},
+		"lastval":            {},
+		"pg_notify":          {},
+		"set_config":         {},
+		"txid_current":       {},
+		"pg_current_xact_id": {},
+		"last_insert_id":     {},
+		"get_lock":           {},
+		"release_lock":       {},
+	}
+
+	volatileFunctionPrefixes = []string{"pg_advisory_", "pg_try_advisory_"}
+
+	reservedKeywords = map[string]struct{}{
+		"select": {}, "from": {}, "where": {}, "join": {}, "inner": {},
+		"outer": {}, "left": {}, "right": {}, "full": {}, "cross": {},
+		"natural": {}, "on": {}, "using": {}, "group": {}, "order": {},
+		"limit": {}, "offset": {}, "having": {}, "union": {}, "intersect": {},
+		"except": {}, "for": {}, "set": {}, "values": {}, "returning": {},
+		"as": {}, "window": {}, "fetch": {}, "into": {}, "lateral": {},
+		"only": {}, "and": {}, "or": {}, "not": {}, "default": {}, "with": {},
+		"do": {}, "lock": {},
+	}
+)
+
+func init() {
+	for kw, t := range writeKeywords {
+		statementKeywords[kw] = t
+	}
+}
+
+type parser struct {
+	ts []Token
+
+	ctes   map[string]struct{}
+	tables map[string]struct{}
+	st     Statement
+
+	// into is set when the statement holds an INTO clause, it turns a
+	// SELECT into a write as SELECT ... INTO creates a table.
+	into bool
+}
+
+func parseStatement(stmt string) Statement {
+	var p = parser{
+		ts:     significantTokens(stmt),
+		ctes:   make(map[string]struct{}),
+		tables: make(map[string]struct{}),
+	}
+
+	p.parse()
+
+	return p.st
+}
+
+func significantTokens(stmt string) []Token {
+	var (
+		l  = NewLexer(stmt)
+		ts []Token
+	)
+
+	for t := l.Next(); t.Kind != TokenEOF; t = l.Next() {
+		if t.Kind != TokenWhitespace && t.Kind != TokenComment {
+			ts = append(ts, t)
+		}
+	}
+
+	return ts
+}
+
+func (p *parser) word(i int) string {
+	if i < 0 || i >= len(p.ts) || p.ts[i].Kind != TokenWord {
+		return ""
+	}
+
+	return strings.ToLower(p.ts[i].Value)
+}
+
+func (p *parser) isPunct(i int, v string) bool {
+	return i < len(p.ts) && p.ts[i].Kind == TokenPunctuation && p.ts[i].Value == v
+}
+
+// skipParens returns the index following the parenthesis closing the one
+// opened at i.
+func (p *parser) skipParens(i int) int {
+	var depth int
+
+	for ; i < len(p.ts); i++ {
+		switch {
+		case p.isPunct(i, "("):
+			depth++
+		case p.isPunct(i, ")"):
+			depth--
+
+			if depth == 0 {
+				return i + 1
+			}
+		}
+	}
+
+	return i
+}
+
+func (p *parser) parse() {
+	var (
+		readOnly, writes bool
+
+		i = p.skipOpeningParens(0)
+	)
+
+	if p.word(i) == "with" {
+		i, writes = p.skipCTEs(i + 1)
+		i = p.skipOpeningParens(i)
+	}
+
+	p.st.Type, readOnly = p.classify(i)
+
+	p.scan()
+
+	if p.st.Type == StmtSelect {
+		readOnly = !writes && !p.into && p.st.Locking == NoLocking
+	}
+
+	p.st.ReadOnly = readOnly && !p.callsVolatileFunction()
+}
+
+func (p *parser) skipOpeningParens(i int) int {
+	for p.isPunct(i, "(") {
+		i++
+	}
+
+	return i
+}
+
+// classify returns the type of the statement starting at i and whether it
+// is read only on its own.
+func (p *parser) classify(i int) (StmtType, bool) {
+	kw := p.word(i)
+	t, ok := statementKeywords[kw]
+
+	if !ok {
+		return StmtUnknown, false
+	}
+
+	switch t {
+	case StmtSelect:
+		return t, true
+	case StmtUtility:
+		if kw == "explain" {
+			return t, p.explainIsReadOnly(i + 1)
+		}
+
+		_, ro := readOnlyUtilities[kw]
+
+		return t, ro
+	}
+
+	return t, false
+}
+
+// explainIsReadOnly reports whether the EXPLAIN whose options start at i
+// does not execute a statement with side effects.
+func (p *parser) explainIsReadOnly(i int) bool {
+	var analyze bool
+
+	for {
+		switch kw := p.word(i); {
+		case kw == "analyze" || kw == "analyse":
+			analyze = true
+			i++
+		case kw == "verbose":
+			i++
+		case p.isPunct(i, "("):
+			end := p.skipParens(i)
+
+			for j := i + 1; j < end; j++ {
+				if kw := p.word(j); kw == "analyze" || kw == "analyse" {
+					analyze = !(p.word(j+1) == "false" || p.word(j+1) == "off")
+				}
+			}
+
+			i = end
+		default:
+			if !analyze {
+				return true
+			}
+
+			t, ro := p.classify(i)
+
+			return t == StmtSelect || ro
+		}
+	}
+}
+
+// skipCTEs skips the common table expressions starting at i and returns the
+// index of the main statement along with whether one of the expressions
+// writes.
+func (p *parser) skipCTEs(i int) (int, bool) {
+	var writes bool
+
+	if p.word(i) == "recursive" {
+		i++
+	}
+
+	for i < len(p.ts) {
+		if name, ok := p.identifier(i); ok {
+			p.ctes[name] = struct{}{}
+		}
+
+		i++
+
+		if p.isPunct(i, "(") {
+			i = p.skipParens(i)
+		}
+
+		for _, kw := range []string{"as", "not", "materialized"} {
+			if p.word(i) == kw {
+				i++
+			}
+		}
+
+		if p.isPunct(i, "(") {
+			if _, ok := writeKeywords[p.word(p.skipOpeningParens(i))]; ok {
+				writes = true
+			}
+
+			i = p.skipParens(i)
+		}
+
+		for i < len(p.ts) && !p.isPunct(i, ",") {
+			if _, ok := statementKeywords[p.word(i)]; ok {
+				break
+			}
+
+			i++
+		}
+
+		if !p.isPunct(i, ",") {
+			break
+		}
+
+		i++
+	}
+
+	if _, ok := writeKeywords[p.word(i)]; ok {
+		writes = true
+	}
+
+	return i, writes
+}
+
+// identifier returns the unquoted identifier at i.
+func (p *parser) identifier(i int) (string, bool) {
+	if i >= len(p.ts) {
+		return "", false
+	}
+
+	switch t := p.ts[i]; t.Kind {
+	case TokenWord:
+		if _, ok := reservedKeywords[strings.ToLower(t.Value)]; ok {
+			return "", false
+		}
+
+		return t.Value, true
+	case TokenQuotedIdentifier:
+		q := t.Value[:1]
+		v := strings.TrimSuffix(strings.TrimPrefix(t.Value, q), q)
+
+		return strings.ReplaceAll(v, q+q, q), true
+	}
+
+	return "", false
+}
+
+// qualifiedName returns the possibly schema qualified name starting at i and
+// the index following it.
+func (p *parser) qualifiedName(i int) (string, int, bool) {
+	var parts []string
+
+	for {
+		name, ok := p.identifier(i)
+
+		if !ok {
+			return "", i, false
+		}
+
+		parts = append(parts, name)
+		i++
+
+		if !p.isPunct(i, ".") {
+			return strings.Join(parts, "."), i, true
+		}
+
+		i++
+	}
+}
+
+func (p *parser) addTable(name string) {
+	if _, ok := p.ctes[name]; ok {
+		return
+	}
+
+	if _, ok := p.tables[name]; ok {
+		return
+	}
+
+	p.tables[name] = struct{}{}
+	p.st.Tables = append(p.st.Tables, name)
+}
+
+// scan walks through the whole statement to collect the referenced tables
+// and the locking clause.
+func (p *parser) scan() {
+	for i := range p.ts {
+		switch p.word(i) {
+		case "from":
+			p.tableList(i+1, true)
+		case "join":
+			p.tableList(i+1, false)
+		case "into":
+			p.into = true
+			p.table(i + 1)
+		case "using":
+			p.table(i + 1)
+		case "update":
+			switch p.word(i - 1) {
+			case "for", "key", "do", "on":
+			default:
+				p.table(i + 1)
+			}
+		case "table", "truncate":
+			j := i + 1
+
+			for _, kw := range []string{"if", "not", "exists"} {
+				if p.word(j) == kw {
+					j++
+				}
+			}
+
+			p.nameList(j)
+		case "for":
+			p.lockingClause(i + 1)
+		case "lock":
+			if p.word(i+1) == "in" && p.word(i+2) == "share" {
+				p.setLocking(ForShare)
+			}
+		}
+	}
+}
+
+func (p *parser) table(i int) int {
+	if p.word(i) == "only" {
+		i++
+	}
+
+	name, j, ok := p.qualifiedName(i)
+
+	if ok {
+		p.addTable(name)
+	}
+
+	return j
+}
+
+// nameList collects the comma separated table names starting at i, as found
+// in DDL statements.
+func (p *parser) nameList(i int) {
+	for {
+		j := p.table(i)
+
+		if j == i || !p.isPunct(j, ",") {
+			return
+		}
+
+		i = j + 1
+	}
+}
+
+// tableList collects the comma separated tables starting at i, functions
+// and sub queries are skipped.
+func (p *parser) tableList(i int, list bool) {
+	for {
+		for p.word(i) == "only" || p.word(i) == "lateral" {
+			i++
+		}
+
+		name, j, ok := p.qualifiedName(i)
+
+		if !ok {
+			return
+		}
+
+		if p.isPunct(j, "(") {
+			j = p.skipParens(j)
+		} else {
+			p.addTable(name)
+		}
+
+		if !list {
+			return
+		}
+
+		if p.word(j) == "as" {
+			j++
+		}
+
+		if _, ok := p.identifier(j); ok {
+			j++
+		}
+
+		if p.isPunct(j, "(") {
+			j = p.skipParens(j)
+		}
+
+		if !p.isPunct(j, ",") {
+			return
+		}
+
+		i = j + 1
+	}
+}
+
+func (p *parser) lockingClause(i int) {
+	switch p.word(i) {
+	case "update":
+		p.setLocking(ForUpdate)
+	case "share":
+		p.setLocking(ForShare)
+	case "no":
+		if p.word(i+1) == "key" && p.word(i+2) == "update" {
+			p.setLocking(ForNoKeyUpdate)
+		}
+	case "key":
+		if p.word(i+1) == "share" {
+			p.setLocking(ForKeyShare)
+		}
+	}
+}
+
+func (p *parser) setLocking(l LockingClause) {
+	if p.st.Locking == NoLocking {
+		p.st.Locking = l
+	}
+}
+
+func (p *parser) callsVolatileFunction() bool {
+	for i := range p.ts {
+		fn := p.word(i)
+
+		if fn == "" || !p.isPunct(i+1, "(") {
+			continue
+		}
+
+		if _, ok := volatileFunctions[fn]; ok {
+			return true
+		}
+
+		for _, prefix := range volatileFunctionPrefixes {
+			if strings.HasPrefix(fn, prefix) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,231 @@
+package sqlparser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseStatement(t *testing.T) {
+	tests := []struct {
+		name string
+		stmt string
+		want Statement
+	}{
+		{
+			name: "select",
+			stmt: "SELECT a.x, b.y FROM foo a, public.bar AS b JOIN \"Baz\" ON true",
+			want: Statement{
+				Type:     StmtSelect,
+				ReadOnly: true,
+				Tables:   []string{"foo", "public.bar", "Baz"},
+			},
+		},
+		{
+			name: "select function",
+			stmt: "SELECT * FROM generate_series(1, 10) s, foo WHERE x IN (SELECT y FROM bar)",
+			want: Statement{
+				Type:     StmtSelect,
+				ReadOnly: true,
+				Tables:   []string{"foo", "bar"},
+			},
+		},
+		{
+			name: "select for update",
+			stmt: "SELECT * FROM foo WHERE id = $1 FOR UPDATE",
+			want: Statement{
+				Type:    StmtSelect,
+				Locking: ForUpdate,
+				Tables:  []string{"foo"},
+			},
+		},
+		{
+			name: "select for no key update",
+			stmt: "SELECT * FROM foo FOR NO KEY UPDATE SKIP LOCKED",
+			want: Statement{
+				Type:    StmtSelect,
+				Locking: ForNoKeyUpdate,
+				Tables:  []string{"foo"},
+			},
+		},
+		{
+			name: "nextval",
+			stmt: "SELECT nextval('foo_id_seq')",
+			want: Statement{Type: StmtSelect},
+		},
+		{
+			name: "select into",
+			stmt: "SELECT * INTO new_foo FROM foo WHERE x > 1",
+			want: Statement{Type: StmtSelect, Tables: []string{"new_foo", "foo"}},
+		},
+		{
+			name: "with select",
+			stmt: "WITH RECURSIVE t(n) AS (SELECT 1 UNION ALL SELECT n + 1 FROM t) SELECT * FROM t JOIN foo ON true",
+			want: Statement{
+				Type:     StmtSelect,
+				ReadOnly: true,
+				Tables:   []string{"foo"},
+			},
+		},
+		{
+			name: "with data modifying select",
+			stmt: "WITH d AS (DELETE FROM foo RETURNING *) SELECT * FROM d",
+			want: Statement{Type: StmtSelect, Tables: []string{"foo"}},
+		},
+		{
+			name: "with insert",
+			stmt: "WITH v AS MATERIALIZED (SELECT 1) INSERT INTO foo(x) SELECT * FROM v",
+			want: Statement{Type: StmtInsert, Tables: []string{"foo"}},
+		},
+		{
+			name: "upsert",
+			stmt: "INSERT INTO foo(x) VALUES ($1) ON CONFLICT (x) DO UPDATE SET x = 1",
+			want: Statement{Type: StmtInsert, Tables: []string{"foo"}},
+		},
+		{
+			name: "update",
+			stmt: "UPDATE ONLY foo SET x = 1 FROM bar WHERE foo.id = bar.id",
+			want: Statement{Type: StmtUpdate, Tables: []string{"foo", "bar"}},
+		},
+		{
+			name: "delete using",
+			stmt: "DELETE FROM foo USING bar WHERE foo.id = bar.id",
+			want: Statement{Type: StmtDelete, Tables: []string{"foo", "bar"}},
+		},
+		{
+			name: "create table",
+			stmt: "CREATE TABLE IF NOT EXISTS foo (id INTEGER REFERENCES bar ON UPDATE CASCADE)",
+			want: Statement{Type: StmtDDL, Tables: []string{"foo"}},
+		},
+		{
+			name: "drop tables",
+			stmt: "DROP TABLE foo, bar",
+			want: Statement{Type: StmtDDL, Tables: []string{"foo", "bar"}},
+		},
+		{
+			name: "begin",
+			stmt: "BEGIN",
+			want: Statement{Type: StmtTxControl},
+		},
+		{
+			name: "explain",
+			stmt: "EXPLAIN SELECT * FROM foo",
+			want: Statement{
+				Type:     StmtUtility,
+				ReadOnly: true,
+				Tables:   []string{"foo"},
+			},
+		},
+		{
+			name: "explain analyze delete",
+			stmt: "EXPLAIN (ANALYZE, BUFFERS) DELETE FROM foo",
+			want: Statement{Type: StmtUtility, Tables: []string{"foo"}},
+		},
+		{
+			name: "explain analyze select",
+			stmt: "EXPLAIN ANALYZE SELECT 1",
+			want: Statement{Type: StmtUtility, ReadOnly: true},
+		},
+		{
+			name: "show",
+			stmt: "SHOW search_path",
+			want: Statement{Type: StmtUtility, ReadOnly: true},
+		},
+		{
+			name: "commented",
+			stmt: "/* SELECT */ -- UPDATE\n UPDATE foo SET x = 'SELECT'",
+			want: Statement{Type: StmtUpdate, Tables: []string{"foo"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseStatement(tt.stmt); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseStatement() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+type countingParser struct {
+	sqlParser
+
+	calls int
+}
+
+func (cp *countingParser) ParseStatement(stmt string) Statement {
+	cp.calls++
+	return cp.sqlParser.ParseStatement(stmt)
+}
+
+func TestCachedParser(t *testing.T) {
+	var (
+		cp countingParser
+
+		p = NewCachedParser(&cp, 2)
+	)
+
+	for _, stmt := range []string{"SELECT 1", "SELECT 1", "SELECT 2", "SELECT 1", "SELECT 3", "SELECT 2"} {
+		if got := p.GetStatementType(stmt); got != StmtSelect {
+			t.Errorf("GetStatementType(%q) = %v, want %v", stmt, got, StmtSelect)
+		}
+	}
+
+	if cp.calls != 4 {
+		t.Errorf("calls = %d, want %d", cp.calls, 4)
+	}
+}
+
+func TestCachedParserDisabled(t *testing.T) {
+	for _, size := range []int{0, -1} {
+		var (
+			cp countingParser
+
+			p = NewCachedParser(&cp, size)
+		)
+
+		for i := 0; i < 2; i++ {
+			if got := p.ParseStatement("SELECT 1").Type; got != StmtSelect {
+				t.Errorf("ParseStatement().Type = %v, want %v", got, StmtSelect)
+			}
+		}
+
+		if cp.calls != 2 {
+			t.Errorf("calls = %d, want %d", cp.calls, 2)
+		}
+	}
+}
+
+func TestCachedParserComments(t *testing.T) {
+	var (
+		cp countingParser
+
+		p = NewCachedParser(&cp, 2)
+	)
+
+	for _, stmt := range []string{
+		"SELECT 1",
+		"SELECT 1 /*trace='a'*/",
+		"/*trace='b'*/ SELECT 1",
+		"/* a */ /* /* b */ */ SELECT 1 /*c*/ ",
+	} {
+		if got := p.ParseStatement(stmt).Type; got != StmtSelect {
+			t.Errorf("ParseStatement(%q).Type = %v, want %v", stmt, got, StmtSelect)
+		}
+	}
+
+	if cp.calls != 1 {
+		t.Errorf("calls = %d, want %d", cp.calls, 1)
+	}
+
+	for stmt, want := range map[string]string{
+		"SELECT 1 /*c*/":               "SELECT 1",
+		"SELECT '/*' || '*/'":          "SELECT '/*' || '*/'",
+		"SELECT 1 -- x /* y */":        "SELECT 1 -- x",
+		"/* unterminated SELECT 1":     "",
+		"SELECT 1 /* a */ + 2 /* b */": "SELECT 1 /* a */ + 2",
+	} {
+		if got := cacheKey(stmt); got != want {
+			t.Errorf("cacheKey(%q) = %q, want %q", stmt, got, want)
+		}
+	}
+}
@@ -36,6 +36,18 @@ func (tl testLogger) Log(ot logger.OpType, q string, vs []interface{}, d time.Du
 func buildPostgres(t testing.TB) (sqlutil.Option, func()) {
 	t.Helper()
 
+	return buildPostgresDriver(t, "postgres")
+}
+
+func buildPgx(t testing.TB) (sqlutil.Option, func()) {
+	t.Helper()
+
+	return buildPostgresDriver(t, "pgx")
+}
+
+func buildPostgresDriver(t testing.TB, driver string) (sqlutil.Option, func()) {
+	t.Helper()
+
 	dsn := os.Getenv("POSTGRES_URL")
 
 	if dsn == "" {
@@ -44,7 +56,7 @@ func buildPostgres(t testing.TB) (sqlutil.Option, func()) {
 		return nil, nil
 	}
 
-	return sqlutil.WithMaster("postgres", dsn), func() {}
+	return sqlutil.WithMaster(driver, dsn), func() {}
 }
 
 func buildSQLite(t testing.TB) (sqlutil.Option, func()) {
@@ -70,6 +82,7 @@ func defaultTestCaseFunc(t testing.TB) []dbCase {
 
 	for n, fn := range map[string]func(testing.TB) (sqlutil.Option, func()){
 		"postgres": buildPostgres,
+		"pgx":      buildPgx,
 		"sqlite3":  buildSQLite,
 	} {
 		opt, clean := fn(t)
@@ -2,12 +2,14 @@ package sqlutil
 
 import (
 	stdsql "database/sql"
+	"fmt"
 	"sync"
 	"time"
 
 	"github.com/upfluence/errors"
 
 	"github.com/upfluence/sql"
+	"github.com/upfluence/sql/backend/balancer"
 	"github.com/upfluence/sql/backend/postgres"
 	"github.com/upfluence/sql/backend/replication"
 	"github.com/upfluence/sql/backend/roundrobin"
@@ -15,9 +17,14 @@ import (
 	"github.com/upfluence/sql/sqlparser"
 )
 
+const defaultParserCacheSize = 1024
+
 var (
 	defaultOptions = &builder{
-		parser: sqlparser.DefaultSQLParser(),
+		parser: sqlparser.NewCachedParser(
+			sqlparser.DefaultSQLParser(),
+			defaultParserCacheSize,
+		),
 		options: []DBOption{
 			WithMaxOpenConns(128),
 			WithMaxIdleConns(16),
@@ -36,6 +43,8 @@ type AdhocDBConfig struct {
 	MaxOpenConns    *int           `env:"MAX_OPEN_CONNS"`
 	ConnMaxLifetime *time.Duration `env:"CONN_MAX_LIFETIME"`
 	ConnMaxIdleTime *time.Duration `env:"CONN_MAX_IDLE_TIME"`
+
+	StatementCacheSize *int `env:"STATEMENT_CACHE_SIZE"`
 }
 
 func (ac *AdhocDBConfig) Options() []DBOption {
@@ -57,6 +66,10 @@ func (ac *AdhocDBConfig) Options() []DBOption {
 		res = append(res, WithConnMaxIdleTime(*ac.ConnMaxIdleTime))
 	}
 
+	if ac.StatementCacheSize != nil {
+		res = append(res, WithStatementCache(*ac.StatementCacheSize))
+	}
+
 	return res
 }
 
@@ -124,6 +137,12 @@ func WithConnMaxLifetime(v time.Duration) DBOption {
 	}
 }
 
+// WithStatementCache enables a cache of the size most recently used prepared
+// statements on each of the DBs.
+func WithStatementCache(size int) DBOption {
+	return func(i *dbInput) { i.stmtCacheSize = size }
+}
+
 type dbInput struct {
 	isMaster bool
 
@@ -135,6 +154,8 @@ type dbInput struct {
 	maxLifetime  *time.Duration
 	maxIdleTime  *time.Duration
 
+	stmtCacheSize int
+
 	dbCallbacks []func(*stdsql.DB)
 }
 
@@ -169,7 +190,11 @@ func (i *dbInput) buildDB(p sqlparser.SQLParser) (sql.DB, error) {
 		fn(plainDB)
 	}
 
-	db := simple.FromStdDB(plainDB, i.driver)
+	db := simple.FromStdDB(
+		plainDB,
+		i.driver,
+		simple.WithStatementCache(i.stmtCacheSize),
+	)
 
 	if wfn, ok := driverWrappers[i.driver]; ok {
 		db = wfn(db, p)
@@ -224,10 +249,32 @@ func (b *builder) buildDB() (sql.DB, error) {
 		slaves = append(slaves, masters...)
 	}
 
+	var (
+		slaveDB = dbs(slaves).buildDB()
+		opts    []replication.Option
+	)
+
+	if m := b.lagMonitor; m != nil {
+		for i, s := range slaves {
+			m.Watch(fmt.Sprintf("replica-%d", i), s)
+		}
+
+		slaveDB = balancer.NewDB(
+			replication.FreshBalancerBuilder(m, balancer.RoundRobinBalancerBuilder),
+			slaves...,
+		)
+		opts = append(opts, replication.WithLagMonitor(m))
+	}
+
+	if b.stickyWindow > 0 {
+		opts = append(opts, replication.WithReadYourWrites(b.stickyWindow))
+	}
+
 	return replication.NewDB(
 		dbs(masters).buildDB(),
-		dbs(slaves).buildDB(),
+		slaveDB,
 		b.parser,
+		opts...,
 	), nil
 }
 
@@ -238,6 +285,9 @@ type builder struct {
 
 	useMasterForReads bool
 
+	lagMonitor   *replication.LagMonitor
+	stickyWindow time.Duration
+
 	parser sqlparser.SQLParser
 }
 
@@ -255,6 +305,19 @@ func WithDatabase(driver, dsn string, readOnly bool, opts ...DBOption) Option {
 	return func(b *builder) { b.dbs = append(b.dbs, &i) }
 }
 
+// WithReplicationLagMonitor watches the lag of every slave, the reads are
+// only routed to the slaves the monitor considers fresh and fallback to the
+// masters when none is.
+func WithReplicationLagMonitor(m *replication.LagMonitor) Option {
+	return func(b *builder) { b.lagMonitor = m }
+}
+
+// WithReadYourWrites routes the reads carrying a sql.Session to the masters
+// for the given window after the session issued a write.
+func WithReadYourWrites(window time.Duration) Option {
+	return func(b *builder) { b.stickyWindow = window }
+}
+
 func WithGlobalDBOptions(opts ...DBOption) Option {
 	return func(b *builder) { b.options = append(b.options, opts...) }
 }
@@ -3,9 +3,13 @@
 package sqlutil
 
 import (
+	"context"
+	"reflect"
 	"testing"
 
+	"github.com/upfluence/sql"
 	"github.com/upfluence/sql/backend/sqlite3"
+	"github.com/upfluence/sql/middleware/sqlcommenter"
 )
 
 func TestOpenSQLite3DB(t *testing.T) {
@@ -19,3 +23,66 @@ func TestOpenSQLite3DB(t *testing.T) {
 		t.Errorf("invalid wrapping of the DB")
 	}
 }
+
+func TestOpenStats(t *testing.T) {
+	db, err := Open(
+		WithMaster("sqlite3", ":memory:"),
+		WithSlave("sqlite3", ":memory:"),
+		WithSlave("sqlite3", ":memory:"),
+		WithMiddleware(sqlcommenter.NewFactory()),
+	)
+
+	if err != nil {
+		t.Fatalf("Open() = (_, %+v) wanted nil", err)
+	}
+
+	var nodes []string
+
+	for _, ns := range db.(sql.StatsProvider).Stats() {
+		nodes = append(nodes, ns.Node)
+	}
+
+	if want := []string{"master", "replica/0", "replica/1"}; !reflect.DeepEqual(nodes, want) {
+		t.Errorf("Stats() nodes = %v, wanted %v", nodes, want)
+	}
+
+	for _, h := range db.(sql.Pinger).Ping(context.Background()) {
+		if h.Err != nil {
+			t.Errorf("Ping() = %+v for %q, wanted nil", h.Err, h.Node)
+		}
+	}
+}
+
+func TestOpenStatementCacheStats(t *testing.T) {
+	ctx := context.Background()
+	db, err := Open(
+		WithMaster("sqlite3", ":memory:", WithStatementCache(8)),
+		WithSlave("sqlite3", ":memory:", WithStatementCache(8)),
+		WithSlave("sqlite3", ":memory:", WithStatementCache(8)),
+		WithMiddleware(sqlcommenter.NewFactory()),
+	)
+
+	if err != nil {
+		t.Fatalf("Open() = (_, %+v) wanted nil", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := db.Exec(ctx, "SELECT 1"); err != nil {
+			t.Fatalf("Exec() = (_, %+v) wanted nil", err)
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		var v int
+
+		if err := db.QueryRow(ctx, "SELECT 2").Scan(&v); err != nil {
+			t.Fatalf("QueryRow().Scan() = %+v wanted nil", err)
+		}
+	}
+
+	stats := db.(sql.StatementCacher).StatementCacheStats()
+
+	if want := (sql.StatementCacheStats{Hits: 2, Misses: 3, Size: 3}); stats != want {
+		t.Errorf("StatementCacheStats() = %+v, wanted %+v", stats, want)
+	}
+}
@@ -0,0 +1,15 @@
+package sqlutil
+
+import (
+	"github.com/upfluence/sql"
+	"github.com/upfluence/sql/backend/mysql"
+	"github.com/upfluence/sql/sqlparser"
+)
+
+func init() {
+	RegisterDriverWrapper("mysql", newMySQLDB)
+}
+
+func newMySQLDB(db sql.DB, _ sqlparser.SQLParser) sql.DB {
+	return mysql.NewDB(db)
+}
@@ -0,0 +1,7 @@
+package sqlutil
+
+import "github.com/upfluence/sql/backend/pgx"
+
+func init() {
+	RegisterDriverWrapper("pgx", pgx.NewDB)
+}
@@ -0,0 +1,120 @@
+package sql
+
+import (
+	"context"
+	"database/sql"
+)
+
+type DBStats = sql.DBStats
+
+// NodeStats holds the connection pool statistics of one of the databases
+// behind a DB. Node is the path of the routes leading to it, such as
+// "replica/1", and is empty for a standalone database.
+type NodeStats struct {
+	Node  string
+	Stats DBStats
+}
+
+// NodeHealth holds the result of the ping of one of the databases behind a
+// DB, Err is nil when it is reachable.
+type NodeHealth struct {
+	Node string
+	Err  error
+}
+
+// StatsProvider is implemented by the DBs able to report the statistics of
+// their connection pools.
+type StatsProvider interface {
+	Stats() []NodeStats
+}
+
+// Pinger is implemented by the DBs able to check the reachability of the
+// databases they are made of.
+type Pinger interface {
+	Ping(context.Context) []NodeHealth
+}
+
+func nodePath(prefix, node string) string {
+	switch {
+	case prefix == "":
+		return node
+	case node == "":
+		return prefix
+	}
+
+	return prefix + "/" + node
+}
+
+// NodeStatsOf returns the statistics reported by the DB, if it is a
+// StatsProvider, with their nodes prefixed by the given route.
+func NodeStatsOf(prefix string, db DB) []NodeStats {
+	sp, ok := db.(StatsProvider)
+
+	if !ok {
+		return nil
+	}
+
+	stats := sp.Stats()
+
+	for i := range stats {
+		stats[i].Node = nodePath(prefix, stats[i].Node)
+	}
+
+	return stats
+}
+
+// PingNodes pings the DB, if it is a Pinger, and prefixes the nodes of the
+// results with the given route.
+func PingNodes(ctx context.Context, prefix string, db DB) []NodeHealth {
+	p, ok := db.(Pinger)
+
+	if !ok {
+		return nil
+	}
+
+	hs := p.Ping(ctx)
+
+	for i := range hs {
+		hs[i].Node = nodePath(prefix, hs[i].Node)
+	}
+
+	return hs
+}
+
+// StatementCacheStats reports the activity of the prepared statement caches
+// of a DB.
+type StatementCacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Size      int
+}
+
+// StatementCacher is implemented by the DBs caching their prepared
+// statements.
+type StatementCacher interface {
+	StatementCacheStats() StatementCacheStats
+}
+
+// StatementCacheStatsOf sums the statement cache statistics reported by the
+// DBs being StatementCachers.
+func StatementCacheStatsOf(dbs ...DB) StatementCacheStats {
+	var res StatementCacheStats
+
+	for _, db := range dbs {
+		sc, ok := db.(StatementCacher)
+
+		if !ok {
+			continue
+		}
+
+		stats := sc.StatementCacheStats()
+
+		res.Hits += stats.Hits
+		res.Misses += stats.Misses
+		res.Evictions += stats.Evictions
+		res.Size += stats.Size
+	}
+
+	return res
+}
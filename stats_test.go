@@ -0,0 +1,77 @@
+package sql_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/upfluence/sql"
+	"github.com/upfluence/sql/backend/static"
+)
+
+type plainDB struct {
+	sql.DB
+}
+
+func TestNodeStatsOf(t *testing.T) {
+	db := static.DB{DBStats: sql.DBStats{MaxOpenConnections: 4}}
+
+	assert.Equal(
+		t,
+		[]sql.NodeStats{{Stats: sql.DBStats{MaxOpenConnections: 4}}},
+		sql.NodeStatsOf("", &db),
+	)
+	assert.Equal(
+		t,
+		[]sql.NodeStats{
+			{Node: "master", Stats: sql.DBStats{MaxOpenConnections: 4}},
+		},
+		sql.NodeStatsOf("master", &db),
+	)
+	assert.Nil(t, sql.NodeStatsOf("master", plainDB{&db}))
+}
+
+func TestPingNodes(t *testing.T) {
+	var (
+		ctx = context.Background()
+		db  = static.DB{PingErr: sql.ErrConnDone}
+	)
+
+	assert.Equal(
+		t,
+		[]sql.NodeHealth{{Node: "replica", Err: sql.ErrConnDone}},
+		sql.PingNodes(ctx, "replica", &db),
+	)
+	assert.Nil(t, sql.PingNodes(ctx, "replica", plainDB{&db}))
+}
+
+type cachingDB struct {
+	static.DB
+
+	stats sql.StatementCacheStats
+}
+
+func (db *cachingDB) StatementCacheStats() sql.StatementCacheStats {
+	return db.stats
+}
+
+func TestStatementCacheStatsOf(t *testing.T) {
+	assert.Equal(
+		t,
+		sql.StatementCacheStats{Hits: 3, Misses: 5, Evictions: 1, Size: 6},
+		sql.StatementCacheStatsOf(
+			&cachingDB{stats: sql.StatementCacheStats{Hits: 1, Misses: 2, Size: 2}},
+			&static.DB{},
+			&cachingDB{
+				stats: sql.StatementCacheStats{
+					Hits:      2,
+					Misses:    3,
+					Evictions: 1,
+					Size:      4,
+				},
+			},
+		),
+	)
+	assert.Equal(t, sql.StatementCacheStats{}, sql.StatementCacheStatsOf())
+}
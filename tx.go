@@ -3,18 +3,23 @@ package sql
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"sync/atomic"
+	"time"
 
 	"github.com/upfluence/errors"
 )
 
 var (
-	ErrRollback = errors.New("rollback sentinel")
+	ErrRollback              = errors.New("rollback sentinel")
+	ErrSavepointNotSupported = errors.New("savepoints not supported")
 
 	InfiniteRetry = -1
 
 	defaultExecuteTxOptions = executeTxOptions{
 		retryCount: InfiniteRetry,
 		retryCheck: isRetryableError,
+		backoff:    NoBackoff,
 	}
 )
 
@@ -38,11 +43,23 @@ type Tx interface {
 	Rollback() error
 }
 
+// Savepointer is implemented by the transactions able to open nested
+// scopes through SQL savepoints.
+type Savepointer interface {
+	Savepoint(context.Context, string) error
+	ReleaseSavepoint(context.Context, string) error
+	RollbackTo(context.Context, string) error
+}
+
 type QueryerFunc func(Queryer) error
 
 type executeTxOptions struct {
 	retryCount int
 	retryCheck func(error) bool
+
+	backoff     Backoff
+	maxDuration time.Duration
+	retryHooks  []func(int, error)
 }
 
 type ExecuteTxOption func(*executeTxOptions)
@@ -55,6 +72,40 @@ func (opts executeTxOptions) shouldRetry(i int) bool {
 	return i < opts.retryCount
 }
 
+// wait blocks for the backoff delay of the attempt i and notifies the retry
+// hooks beforehand. It returns false, without notifying the hooks, when the
+// retry should be abandoned because the context is done or the delay would
+// exceed the deadline.
+func (opts executeTxOptions) wait(ctx context.Context, deadline time.Time, i int, err error) bool {
+	d := opts.backoff.Backoff(i)
+
+	if !deadline.IsZero() && time.Now().Add(d).After(deadline) {
+		return false
+	}
+
+	if ctx.Err() != nil {
+		return false
+	}
+
+	for _, fn := range opts.retryHooks {
+		fn(i+1, err)
+	}
+
+	if d <= 0 {
+		return true
+	}
+
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-t.C:
+		return true
+	}
+}
+
 func isRetryableError(err error) bool {
 	var re RollbackError
 
@@ -62,7 +113,12 @@ func isRetryableError(err error) bool {
 		return false
 	}
 
-	return re.Type == SerializationFailure || re.Type == Locked
+	switch re.Type {
+	case SerializationFailure, Locked, Deadlock:
+		return true
+	}
+
+	return false
 }
 
 func WithCustomRetryCheck(fn func(error) bool) ExecuteTxOption {
@@ -73,9 +129,29 @@ func WithRetryCount(i int) ExecuteTxOption {
 	return func(opts *executeTxOptions) { opts.retryCount = i }
 }
 
+// WithBackoff sets the policy used to space out the retries.
+func WithBackoff(b Backoff) ExecuteTxOption {
+	return func(opts *executeTxOptions) { opts.backoff = b }
+}
+
+// WithRetryDeadline bounds the overall time spent retrying the transaction,
+// the last error is returned once the deadline would be exceeded.
+func WithRetryDeadline(d time.Duration) ExecuteTxOption {
+	return func(opts *executeTxOptions) { opts.maxDuration = d }
+}
+
+// WithRetryHook registers a function called before each retry with the
+// attempt number (starting at 1) and the error triggering it.
+func WithRetryHook(fn func(int, error)) ExecuteTxOption {
+	return func(opts *executeTxOptions) {
+		opts.retryHooks = append(opts.retryHooks, fn)
+	}
+}
+
 func ExecuteTx(ctx context.Context, db DB, txOpts TxOptions, fn QueryerFunc, exOpts ...ExecuteTxOption) error {
 	var (
-		i int
+		i        int
+		deadline time.Time
 
 		opts = defaultExecuteTxOptions
 	)
@@ -84,6 +160,10 @@ func ExecuteTx(ctx context.Context, db DB, txOpts TxOptions, fn QueryerFunc, exO
 		fn(&opts)
 	}
 
+	if opts.maxDuration > 0 {
+		deadline = time.Now().Add(opts.maxDuration)
+	}
+
 	for {
 		tx, err := db.BeginTx(ctx, txOpts)
 
@@ -99,6 +179,10 @@ func ExecuteTx(ctx context.Context, db DB, txOpts TxOptions, fn QueryerFunc, exO
 				return errors.Wrap(err, "cant commit the tx")
 			}
 
+			if !opts.wait(ctx, deadline, i, err) {
+				return errors.Wrap(err, "cant commit the tx")
+			}
+
 			i++
 		case errors.Is(err, ErrRollback):
 			tx.Rollback()
@@ -106,7 +190,7 @@ func ExecuteTx(ctx context.Context, db DB, txOpts TxOptions, fn QueryerFunc, exO
 		case opts.retryCheck(err):
 			tx.Rollback()
 
-			if !opts.shouldRetry(i) {
+			if !opts.shouldRetry(i) || !opts.wait(ctx, deadline, i, err) {
 				return err
 			}
 
@@ -117,3 +201,42 @@ func ExecuteTx(ctx context.Context, db DB, txOpts TxOptions, fn QueryerFunc, exO
 		}
 	}
 }
+
+var savepointCounter uint64
+
+func nextSavepointName() string {
+	return fmt.Sprintf("sp_%d", atomic.AddUint64(&savepointCounter, 1))
+}
+
+// ExecuteNestedTx behaves like ExecuteTx when given a DB. When given a
+// transaction instead, fn is executed inside a savepoint which is rolled back
+// if fn fails. Retryable errors are not retried at the savepoint level since
+// they abort the whole transaction, they are returned to the caller instead.
+func ExecuteNestedTx(ctx context.Context, q Queryer, txOpts TxOptions, fn QueryerFunc, exOpts ...ExecuteTxOption) error {
+	switch qq := q.(type) {
+	case DB:
+		return ExecuteTx(ctx, qq, txOpts, fn, exOpts...)
+	case Savepointer:
+		return executeSavepoint(ctx, q, qq, fn)
+	default:
+		return ErrSavepointNotSupported
+	}
+}
+
+func executeSavepoint(ctx context.Context, q Queryer, sp Savepointer, fn QueryerFunc) error {
+	name := nextSavepointName()
+
+	if err := sp.Savepoint(ctx, name); err != nil {
+		return errors.Wrap(err, "cant open the savepoint")
+	}
+
+	switch err := fn(q); {
+	case err == nil:
+		return errors.Wrap(sp.ReleaseSavepoint(ctx, name), "cant release the savepoint")
+	case errors.Is(err, ErrRollback):
+		return errors.Wrap(sp.RollbackTo(ctx, name), "cant rollback the savepoint")
+	default:
+		sp.RollbackTo(ctx, name)
+		return err
+	}
+}
@@ -0,0 +1,263 @@
+package sql_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/upfluence/sql"
+	"github.com/upfluence/sql/backend/static"
+)
+
+type savepointTx struct {
+	static.Tx
+
+	calls []string
+	names map[string]struct{}
+}
+
+func (tx *savepointTx) record(c, n string) error {
+	if tx.names == nil {
+		tx.names = make(map[string]struct{})
+	}
+
+	tx.calls = append(tx.calls, c)
+	tx.names[n] = struct{}{}
+
+	return nil
+}
+
+func (tx *savepointTx) Savepoint(_ context.Context, n string) error {
+	return tx.record("SAVEPOINT", n)
+}
+
+func (tx *savepointTx) ReleaseSavepoint(_ context.Context, n string) error {
+	return tx.record("RELEASE", n)
+}
+
+func (tx *savepointTx) RollbackTo(_ context.Context, n string) error {
+	return tx.record("ROLLBACK TO", n)
+}
+
+func TestExecuteNestedTx(t *testing.T) {
+	errFoo := errors.New("foo")
+
+	for _, tt := range []struct {
+		name  string
+		fnErr error
+
+		wantErr   error
+		wantCalls []string
+	}{
+		{name: "success", wantCalls: []string{"SAVEPOINT", "RELEASE"}},
+		{
+			name:      "rollback sentinel",
+			fnErr:     sql.ErrRollback,
+			wantCalls: []string{"SAVEPOINT", "ROLLBACK TO"},
+		},
+		{
+			name:      "error",
+			fnErr:     errFoo,
+			wantErr:   errFoo,
+			wantCalls: []string{"SAVEPOINT", "ROLLBACK TO"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var tx savepointTx
+
+			err := sql.ExecuteNestedTx(
+				context.Background(),
+				&tx,
+				sql.TxOptions{},
+				func(q sql.Queryer) error {
+					assert.Equal(t, &tx, q)
+					return tt.fnErr
+				},
+			)
+
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.wantCalls, tx.calls)
+			assert.Len(t, tx.names, 1)
+		})
+	}
+}
+
+func TestExecuteNestedTxDB(t *testing.T) {
+	var (
+		tx = static.Tx{}
+		db = static.DB{Tx: &tx}
+
+		called bool
+	)
+
+	err := sql.ExecuteNestedTx(
+		context.Background(),
+		&db,
+		sql.TxOptions{},
+		func(q sql.Queryer) error {
+			called = true
+			assert.Equal(t, &tx, q)
+			return nil
+		},
+	)
+
+	assert.NoError(t, err)
+	assert.True(t, called)
+}
+
+func TestExecuteNestedTxNotSupported(t *testing.T) {
+	err := sql.ExecuteNestedTx(
+		context.Background(),
+		&static.Queryer{},
+		sql.TxOptions{},
+		func(sql.Queryer) error { return nil },
+	)
+
+	assert.Equal(t, sql.ErrSavepointNotSupported, err)
+}
+
+func TestExecuteTxRetryHook(t *testing.T) {
+	var (
+		rerr = sql.RollbackError{Type: sql.SerializationFailure, Cause: errors.New("foo")}
+		db   = static.DB{Tx: &static.Tx{CommitErr: rerr}}
+
+		attempts []int
+		delays   []int
+	)
+
+	err := sql.ExecuteTx(
+		context.Background(),
+		&db,
+		sql.TxOptions{},
+		func(sql.Queryer) error { return nil },
+		sql.WithRetryCount(2),
+		sql.WithBackoff(
+			sql.BackoffFunc(func(i int) time.Duration {
+				delays = append(delays, i)
+				return time.Millisecond
+			}),
+		),
+		sql.WithRetryHook(func(i int, err error) {
+			assert.Equal(t, rerr, err)
+			attempts = append(attempts, i)
+		}),
+	)
+
+	assert.ErrorIs(t, err, rerr)
+	assert.Equal(t, []int{1, 2}, attempts)
+	assert.Equal(t, []int{0, 1}, delays)
+}
+
+func TestExecuteTxRetryDeadline(t *testing.T) {
+	var (
+		rerr = sql.RollbackError{Type: sql.Locked, Cause: errors.New("foo")}
+		db   = static.DB{Tx: &static.Tx{CommitErr: rerr}}
+
+		attempts int
+	)
+
+	err := sql.ExecuteTx(
+		context.Background(),
+		&db,
+		sql.TxOptions{},
+		func(sql.Queryer) error { return nil },
+		sql.WithBackoff(
+			sql.BackoffFunc(func(int) time.Duration { return time.Hour }),
+		),
+		sql.WithRetryDeadline(time.Second),
+		sql.WithRetryHook(func(int, error) { attempts++ }),
+	)
+
+	assert.ErrorIs(t, err, rerr)
+	assert.Equal(t, 0, attempts)
+}
+
+func TestExecuteTxRetryCanceled(t *testing.T) {
+	var (
+		rerr = sql.RollbackError{Type: sql.Locked, Cause: errors.New("foo")}
+		db   = static.DB{Tx: &static.Tx{CommitErr: rerr}}
+
+		ctx, cancel = context.WithCancel(context.Background())
+
+		attempts int
+	)
+
+	cancel()
+
+	err := sql.ExecuteTx(
+		ctx,
+		&db,
+		sql.TxOptions{},
+		func(sql.Queryer) error { return nil },
+		sql.WithRetryHook(func(int, error) { attempts++ }),
+	)
+
+	assert.ErrorIs(t, err, rerr)
+	assert.Equal(t, 0, attempts)
+}
+
+func TestExecuteTxRetryDeadlock(t *testing.T) {
+	var (
+		attempts int
+
+		rerr = sql.RollbackError{Type: sql.Deadlock, Cause: errors.New("foo")}
+		db   = static.DB{Tx: &static.Tx{}}
+	)
+
+	err := sql.ExecuteTx(
+		context.Background(),
+		&db,
+		sql.TxOptions{},
+		func(sql.Queryer) error {
+			attempts++
+
+			if attempts == 1 {
+				return rerr
+			}
+
+			return nil
+		},
+	)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, attempts)
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	b := sql.ExponentialBackoff{
+		Initial:    10 * time.Millisecond,
+		Max:        50 * time.Millisecond,
+		Multiplier: 2,
+	}
+
+	for i, want := range []time.Duration{
+		10 * time.Millisecond,
+		20 * time.Millisecond,
+		40 * time.Millisecond,
+		50 * time.Millisecond,
+	} {
+		assert.Equal(t, want, b.Backoff(i))
+	}
+
+	b.Jitter = 0.5
+
+	for i := 0; i < 100; i++ {
+		d := b.Backoff(1)
+
+		assert.True(t, d > 10*time.Millisecond && d <= 20*time.Millisecond)
+	}
+}
+
+func TestExponentialBackoffUncapped(t *testing.T) {
+	for _, b := range []sql.ExponentialBackoff{
+		{Initial: time.Second, Multiplier: 2},
+		{Initial: time.Second, Multiplier: 2, Jitter: 0.5},
+	} {
+		for _, i := range []int{40, 64, 100, 2000} {
+			assert.Greater(t, b.Backoff(i), time.Duration(0))
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package migration
 
-import "sync"
+import (
+	"strings"
+	"sync"
+)
 
 var (
 	defaultDriver = &driver{name: "default"}
@@ -13,6 +16,12 @@ var (
 	driversMu = &sync.Mutex{}
 	drivers   = map[string]Driver{
 		"postgres": PostgresDriver,
+		"pgx":      PostgresDriver,
+		"mysql": &driver{
+			name:       "mysql",
+			extensions: []string{"mysql"},
+			quote:      "`",
+		},
 		"sqlite3": &driver{
 			name:       "sqlite3",
 			extensions: []string{"sqlite3", "sqlite"},
@@ -32,6 +41,28 @@ type Driver interface {
 	Extensions() []string
 }
 
+// IdentifierQuoter is implemented by the drivers whose database does not
+// quote the identifiers with double quotes.
+type IdentifierQuoter interface {
+	QuoteIdentifier(string) string
+}
+
+func quoteIdentifier(d Driver, id string) string {
+	if iq, ok := d.(IdentifierQuoter); ok {
+		return iq.QuoteIdentifier(id)
+	}
+
+	return quoteIdentifierWith(`"`, id)
+}
+
+func quoteIdentifierWith(q, id string) string {
+	return q + strings.ReplaceAll(id, q, q+q) + q
+}
+
+// FetchDriver returns the driver registered under the name, or a driver only
+// picking the plain .sql migrations when none is.
+func FetchDriver(dname string) Driver { return fetchDriver(dname) }
+
 func fetchDriver(dname string) Driver {
 	driversMu.Lock()
 	d, ok := drivers[dname]
@@ -47,7 +78,16 @@ func fetchDriver(dname string) Driver {
 type driver struct {
 	name       string
 	extensions []string
+	quote      string
 }
 
 func (d *driver) Name() string         { return d.name }
 func (d *driver) Extensions() []string { return append(d.extensions, "sql") }
+
+func (d *driver) QuoteIdentifier(id string) string {
+	if d.quote == "" {
+		return quoteIdentifierWith(`"`, id)
+	}
+
+	return quoteIdentifierWith(d.quote, id)
+}
@@ -119,7 +119,7 @@ func (m *migrator) downOne(ctx context.Context) (bool, error) {
 			return errors.Wrapf(errM, "migration %d", mi.ID())
 		}
 
-		_, err = q.Exec(ctx, m.opts.deleteMigrationStmt(), mi.ID())
+		_, err = q.Exec(ctx, m.opts.deleteMigrationStmt(m.d), mi.ID())
 
 		return errors.Wrapf(err, "cant remove migration from the table %d", mi.ID())
 	})
@@ -151,7 +151,7 @@ func (m *migrator) upOne(ctx context.Context) (bool, error) {
 			return errors.Wrapf(errM, "migration %d", mi.ID())
 		}
 
-		_, err = q.Exec(ctx, m.opts.addMigrationStmt(), mi.ID(), time.Now())
+		_, err = q.Exec(ctx, m.opts.addMigrationStmt(m.d), mi.ID(), time.Now())
 
 		return errors.Wrapf(err, "cant add migration to the table %d", mi.ID())
 	})
@@ -167,6 +167,7 @@ func (m *migrator) executeTx(ctx context.Context, fn func(sql.Queryer) error) er
 		m,
 		sql.TxOptions{Isolation: sql.LevelSerializable},
 		fn,
+		m.opts.executeTxOptions...,
 	)
 }
 
@@ -0,0 +1,101 @@
+package migration
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/upfluence/sql"
+	"github.com/upfluence/sql/backend/static"
+)
+
+type mysqlDB struct {
+	*static.DB
+}
+
+func (mysqlDB) Driver() string { return "mysql" }
+
+// lastMigrationScanner reads the last migration out of the statements
+// recorded by the transaction.
+type lastMigrationScanner struct {
+	tx *static.Tx
+}
+
+func (lms lastMigrationScanner) Scan(vs ...interface{}) error {
+	var n int64
+
+	for _, q := range lms.tx.ExecQueries {
+		switch {
+		case strings.HasPrefix(q.Query, "INSERT INTO"):
+			n++
+		case strings.HasPrefix(q.Query, "DELETE FROM"):
+			n--
+		}
+	}
+
+	if n > 0 {
+		*vs[0].(*sql.NullInt64) = sql.NullInt64{Int64: n, Valid: true}
+	}
+
+	return nil
+}
+
+func TestMigratorMySQL(t *testing.T) {
+	var (
+		ctx = context.Background()
+		tx  static.Tx
+		db  = mysqlDB{DB: &static.DB{Tx: &tx}}
+		m   = NewMigrator(
+			db,
+			newMockSource(
+				map[string]string{
+					"1_init.up.sql":   "CREATE TABLE foo (id int)",
+					"1_init.down.sql": "DROP TABLE foo",
+				},
+			),
+		)
+	)
+
+	tx.QueryRowScanner = lastMigrationScanner{tx: &tx}
+
+	if err := m.Up(ctx); err != nil {
+		t.Fatalf("Up() = %v, want nil", err)
+	}
+
+	if err := m.Down(ctx); err != nil {
+		t.Fatalf("Down() = %v, want nil", err)
+	}
+
+	var stmts []string
+
+	for _, q := range tx.ExecQueries {
+		stmts = append(stmts, q.Query)
+	}
+
+	want := []string{
+		"CREATE TABLE foo (id int)",
+		"INSERT INTO `migrations` (num, created_at) VALUES ($1, $2)",
+		"DROP TABLE foo",
+		"DELETE FROM `migrations` WHERE num = $1",
+	}
+
+	if strings.Join(stmts, "\n") != strings.Join(want, "\n") {
+		t.Errorf("statements = %q, want %q", stmts, want)
+	}
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	for _, tt := range []struct {
+		driver string
+		want   string
+	}{
+		{driver: "postgres", want: `"my""table"`},
+		{driver: "sqlite3", want: `"my""table"`},
+		{driver: "mysql", want: "`my\"table`"},
+		{driver: "unknown", want: `"my""table"`},
+	} {
+		if got := quoteIdentifier(fetchDriver(tt.driver), `my"table`); got != tt.want {
+			t.Errorf("quoteIdentifier(%q) = %s, want %s", tt.driver, got, tt.want)
+		}
+	}
+}
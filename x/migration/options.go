@@ -1,6 +1,10 @@
 package migration
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/upfluence/sql"
+)
 
 const (
 	createTableMigrationStmtTmpl = `
@@ -10,8 +14,8 @@ create table if not exists %s (
 )
 	`
 	lastMigrationStmtTmpl   = `select max(num) from %s`
-	addMigrationStmtTmpl    = `INSERT INTO "%s" (num, created_at) VALUES ($1, $2)`
-	deleteMigrationStmtTmpl = `DELETE FROM "%s" WHERE num = $1`
+	addMigrationStmtTmpl    = `INSERT INTO %s (num, created_at) VALUES ($1, $2)`
+	deleteMigrationStmtTmpl = `DELETE FROM %s WHERE num = $1`
 )
 
 var defaultOptions = &options{migrationTable: "migrations"}
@@ -26,9 +30,16 @@ func AddErrorTransformer(v ErrorTransformer) Option {
 	return func(o *options) { o.transformers = append(o.transformers, v) }
 }
 
+func WithExecuteTxOptions(opts ...sql.ExecuteTxOption) Option {
+	return func(o *options) {
+		o.executeTxOptions = append(o.executeTxOptions, opts...)
+	}
+}
+
 type options struct {
-	migrationTable string
-	transformers   []ErrorTransformer
+	migrationTable   string
+	transformers     []ErrorTransformer
+	executeTxOptions []sql.ExecuteTxOption
 }
 
 func (o *options) errorTransformer() ErrorTransformer {
@@ -43,10 +54,10 @@ func (o *options) lastMigrationStmt() string {
 	return fmt.Sprintf(lastMigrationStmtTmpl, o.migrationTable)
 }
 
-func (o *options) addMigrationStmt() string {
-	return fmt.Sprintf(addMigrationStmtTmpl, o.migrationTable)
+func (o *options) addMigrationStmt(d Driver) string {
+	return fmt.Sprintf(addMigrationStmtTmpl, quoteIdentifier(d, o.migrationTable))
 }
 
-func (o *options) deleteMigrationStmt() string {
-	return fmt.Sprintf(deleteMigrationStmtTmpl, o.migrationTable)
+func (o *options) deleteMigrationStmt(d Driver) string {
+	return fmt.Sprintf(deleteMigrationStmtTmpl, quoteIdentifier(d, o.migrationTable))
 }
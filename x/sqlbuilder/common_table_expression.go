@@ -0,0 +1,109 @@
+package sqlbuilder
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// CommonTableExpression names the rows yielded by a statement so the
+// statement it is attached to can read from them as from a table.
+type CommonTableExpression struct {
+	Name    string
+	Columns []string
+
+	Statement SelectStatement
+
+	// RecursiveStatement is combined to Statement with UNION ALL and can read
+	// from the expression itself, the WITH clause is then RECURSIVE.
+	RecursiveStatement *SelectStatement
+}
+
+func (cte CommonTableExpression) Clone() CommonTableExpression {
+	var rs *SelectStatement
+
+	if cte.RecursiveStatement != nil {
+		s := cte.RecursiveStatement.Clone()
+		rs = &s
+	}
+
+	var cs []string
+
+	if len(cte.Columns) > 0 {
+		cs = append(cs, cte.Columns...)
+	}
+
+	return CommonTableExpression{
+		Name:               cte.Name,
+		Columns:            cs,
+		Statement:          cte.Statement.Clone(),
+		RecursiveStatement: rs,
+	}
+}
+
+func (cte CommonTableExpression) WriteTo(w QueryWriter, vs map[string]interface{}) error {
+	io.WriteString(w, cte.Name)
+
+	if len(cte.Columns) > 0 {
+		fmt.Fprintf(w, "(%s)", strings.Join(cte.Columns, ", "))
+	}
+
+	io.WriteString(w, " AS (")
+
+	if _, err := cte.Statement.writeTo(w, vs); err != nil {
+		return err
+	}
+
+	if rs := cte.RecursiveStatement; rs != nil {
+		io.WriteString(w, " UNION ALL ")
+
+		if _, err := rs.writeTo(w, vs); err != nil {
+			return err
+		}
+	}
+
+	_, err := io.WriteString(w, ")")
+	return err
+}
+
+func writeCommonTableExpressions(ctes []CommonTableExpression, w QueryWriter, vs map[string]interface{}) error {
+	if len(ctes) == 0 {
+		return nil
+	}
+
+	io.WriteString(w, "WITH ")
+
+	for _, cte := range ctes {
+		if cte.RecursiveStatement != nil {
+			io.WriteString(w, "RECURSIVE ")
+			break
+		}
+	}
+
+	for i, cte := range ctes {
+		if err := cte.WriteTo(w, vs); err != nil {
+			return err
+		}
+
+		if i < len(ctes)-1 {
+			io.WriteString(w, ", ")
+		}
+	}
+
+	_, err := io.WriteString(w, " ")
+	return err
+}
+
+func cloneCommonTableExpressions(ctes []CommonTableExpression) []CommonTableExpression {
+	if len(ctes) == 0 {
+		return nil
+	}
+
+	res := make([]CommonTableExpression, len(ctes))
+
+	for i, cte := range ctes {
+		res[i] = cte.Clone()
+	}
+
+	return res
+}
@@ -0,0 +1,167 @@
+package sqlbuilder
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/upfluence/errors"
+
+	"github.com/upfluence/sql"
+)
+
+var errNoStatements = errors.New("No statement given to the compound statement")
+
+type SetOperator string
+
+const (
+	Union     SetOperator = "UNION"
+	UnionAll  SetOperator = "UNION ALL"
+	Intersect SetOperator = "INTERSECT"
+	Except    SetOperator = "EXCEPT"
+)
+
+// ErrBindingsMismatch is returned when a statement of a compound statement
+// does not select the same bindings, in the same order, as the first one.
+type ErrBindingsMismatch struct {
+	Statement int
+
+	Bindings []string
+	Expected []string
+}
+
+func (ebm ErrBindingsMismatch) Error() string {
+	return fmt.Sprintf(
+		"statement %d selects %v, expected %v",
+		ebm.Statement,
+		ebm.Bindings,
+		ebm.Expected,
+	)
+}
+
+// CompoundStatement combines the rows of its statements with the set
+// operator, the ordering and the pagination apply to the combined rows.
+type CompoundStatement struct {
+	Operator   SetOperator
+	Statements []SelectStatement
+
+	OrderByClauses []OrderByClause
+
+	Offset NullableInt
+	Limit  NullableInt
+
+	Consistency sql.Consistency
+}
+
+func (cs CompoundStatement) Clone() CompoundStatement {
+	var ss []SelectStatement
+
+	if len(cs.Statements) > 0 {
+		ss = make([]SelectStatement, len(cs.Statements))
+
+		for i, s := range cs.Statements {
+			ss[i] = s.Clone()
+		}
+	}
+
+	return CompoundStatement{
+		Operator:       cs.Operator,
+		Statements:     ss,
+		OrderByClauses: cloneOrderByClauses(cs.OrderByClauses),
+		Offset:         cs.Offset,
+		Limit:          cs.Limit,
+		Consistency:    cs.Consistency,
+	}
+}
+
+func selectBindings(ss SelectStatement) []string {
+	bs := make([]string, len(ss.SelectClauses))
+
+	for i, c := range ss.SelectClauses {
+		bs[i] = c.Binding()
+	}
+
+	return bs
+}
+
+func (cs CompoundStatement) buildQuery(vs map[string]interface{}) (string, []interface{}, []string, error) {
+	if len(cs.Statements) == 0 {
+		return "", nil, nil, errNoStatements
+	}
+
+	var (
+		qw queryWriter
+
+		op       = cs.Operator
+		bindings = selectBindings(cs.Statements[0])
+	)
+
+	if op == "" {
+		op = Union
+	}
+
+	for i, ss := range cs.Statements {
+		if bs := selectBindings(ss); !slices.Equal(bs, bindings) {
+			return "", nil, nil, ErrBindingsMismatch{
+				Statement: i,
+				Bindings:  bs,
+				Expected:  bindings,
+			}
+		}
+
+		if i > 0 {
+			fmt.Fprintf(&qw, " %s ", op)
+		}
+
+		// The ordering, the pagination and the WITH clause of a single
+		// statement are ambiguous without parenthesis, the other statements
+		// are left bare as some databases do not accept parenthesized
+		// statements.
+		wrapped := len(ss.With) > 0 || len(ss.OrderByClauses) > 0 ||
+			ss.Limit.Valid || ss.Offset.Valid
+
+		if wrapped {
+			qw.WriteString("(")
+		}
+
+		if _, err := ss.writeTo(&qw, vs); err != nil {
+			return "", nil, nil, err
+		}
+
+		if wrapped {
+			qw.WriteString(")")
+		}
+	}
+
+	if len(cs.OrderByClauses) > 0 {
+		os := make([]string, len(cs.OrderByClauses))
+
+		// The combined rows can only be ordered by the name of their
+		// columns.
+		for i, c := range cs.OrderByClauses {
+			os[i] = columnName(c.Field)
+
+			if c.Direction != "" {
+				os[i] += " " + string(c.Direction)
+			}
+		}
+
+		io.WriteString(&qw, " ORDER BY ")
+		io.WriteString(&qw, strings.Join(os, ", "))
+	}
+
+	if cs.Limit.Valid {
+		fmt.Fprintf(&qw, " LIMIT %d", cs.Limit.Int)
+	}
+
+	if cs.Offset.Valid {
+		fmt.Fprintf(&qw, " OFFSET %d", cs.Offset.Int)
+	}
+
+	if cs.Consistency != sql.EventuallyConsistent {
+		qw.vs = append(qw.vs, cs.Consistency)
+	}
+
+	return qw.String(), qw.vs, bindings, nil
+}
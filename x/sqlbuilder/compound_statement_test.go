@@ -0,0 +1,239 @@
+package sqlbuilder
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/upfluence/sql"
+	"github.com/upfluence/sql/backend/static"
+)
+
+func feedStatement(table string, pc PredicateClause) SelectStatement {
+	return SelectStatement{
+		Table: table,
+		SelectClauses: []Marker{
+			ColumnWithTable("id", table, "id"),
+			SQLExpression("kind", "'"+table+"' AS kind"),
+		},
+		WhereClause: pc,
+	}
+}
+
+func TestCompoundQuery(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+
+		cs CompoundStatement
+		vs map[string]interface{}
+
+		stmt string
+		args []interface{}
+		err  error
+	}{
+		{
+			name: "union",
+			cs: CompoundStatement{
+				Statements: []SelectStatement{
+					feedStatement("posts", Eq(Column("author_id"))),
+					feedStatement("comments", Eq(Column("author_id"))),
+				},
+			},
+			vs:   map[string]interface{}{"author_id": 3},
+			stmt: "SELECT \"posts\".\"id\", 'posts' AS kind FROM posts WHERE author_id = $1 UNION SELECT \"comments\".\"id\", 'comments' AS kind FROM comments WHERE author_id = $2",
+			args: []interface{}{3, 3},
+		},
+		{
+			name: "union all ordered and paginated",
+			cs: CompoundStatement{
+				Operator: UnionAll,
+				Statements: []SelectStatement{
+					feedStatement("posts", StaticEq(Column("state"), "published")),
+					{
+						Table: "likes",
+						SelectClauses: []Marker{
+							ColumnWithTable("id", "likes", "id"),
+							SQLExpression("kind", "'likes' AS kind"),
+						},
+						OrderByClauses: []OrderByClause{{Field: Column("created_at"), Direction: Desc}},
+						Limit:          NullableInt{Int: 5, Valid: true},
+					},
+				},
+				OrderByClauses: []OrderByClause{
+					{Field: ColumnWithTable("id", "posts", "id"), Direction: Desc},
+				},
+				Limit:       NullableInt{Int: 20, Valid: true},
+				Offset:      NullableInt{Int: 40, Valid: true},
+				Consistency: sql.StronglyConsistent,
+			},
+			stmt: "SELECT \"posts\".\"id\", 'posts' AS kind FROM posts WHERE state = $1 UNION ALL (SELECT \"likes\".\"id\", 'likes' AS kind FROM likes ORDER BY created_at DESC LIMIT 5) ORDER BY id DESC LIMIT 20 OFFSET 40",
+			args: []interface{}{"published", sql.StronglyConsistent},
+		},
+		{
+			name: "intersect",
+			cs: CompoundStatement{
+				Operator: Intersect,
+				Statements: []SelectStatement{
+					{Table: "followers", SelectClauses: []Marker{Column("user_id")}},
+					{Table: "subscribers", SelectClauses: []Marker{Column("user_id")}},
+				},
+			},
+			stmt: "SELECT user_id FROM followers INTERSECT SELECT user_id FROM subscribers",
+		},
+		{
+			name: "except",
+			cs: CompoundStatement{
+				Operator: Except,
+				Statements: []SelectStatement{
+					{Table: "users", SelectClauses: []Marker{Column("id")}},
+					{Table: "bans", SelectClauses: []Marker{Column("id")}, WhereClause: Gt(Column("until"))},
+				},
+			},
+			vs:   map[string]interface{}{"until": 10},
+			stmt: "SELECT id FROM users EXCEPT SELECT id FROM bans WHERE until > $1",
+			args: []interface{}{10},
+		},
+		{
+			name: "with clause",
+			cs: CompoundStatement{
+				Statements: []SelectStatement{
+					{Table: "users", SelectClauses: []Marker{Column("id")}},
+					{
+						With: []CommonTableExpression{
+							{
+								Name: "banned",
+								Statement: SelectStatement{
+									Table:         "bans",
+									SelectClauses: []Marker{Column("id")},
+									WhereClause:   Gt(Column("until")),
+								},
+							},
+						},
+						Table:         "banned",
+						SelectClauses: []Marker{Column("id")},
+					},
+				},
+			},
+			vs:   map[string]interface{}{"until": 10},
+			stmt: "SELECT id FROM users UNION (WITH banned AS (SELECT id FROM bans WHERE until > $1) SELECT id FROM banned)",
+			args: []interface{}{10},
+		},
+		{
+			name: "no statement",
+			err:  errNoStatements,
+		},
+		{
+			name: "bindings mismatch",
+			cs: CompoundStatement{
+				Statements: []SelectStatement{
+					{Table: "users", SelectClauses: []Marker{Column("id"), Column("name")}},
+					{Table: "bans", SelectClauses: []Marker{Column("id")}},
+				},
+			},
+			err: ErrBindingsMismatch{
+				Statement: 1,
+				Bindings:  []string{"id"},
+				Expected:  []string{"id", "name"},
+			},
+		},
+		{
+			name: "statement error",
+			cs: CompoundStatement{
+				Statements: []SelectStatement{
+					{Table: "users", SelectClauses: []Marker{Column("id")}},
+					{Table: "bans", SelectClauses: []Marker{Column("id")}, WhereClause: Eq(Column("until"))},
+				},
+			},
+			err: ErrMissingKey{Key: "until"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt, args, _, err := tt.cs.Clone().buildQuery(tt.vs)
+
+			assert.Equal(t, tt.err, err)
+			assert.Equal(t, tt.stmt, stmt)
+			assert.Equal(t, tt.args, args)
+		})
+	}
+}
+
+func TestCompoundQueryer(t *testing.T) {
+	var (
+		ctx = context.Background()
+		db  = static.DB{
+			Queryer: static.Queryer{
+				QueryScanner: &static.MultipleCursor{
+					Scanners: []static.Scanner{
+						{Args: []static.ScanArg{static.Int64Arg(1), static.StringArg("posts")}},
+						{Args: []static.ScanArg{static.Int64Arg(7), static.StringArg("comments")}},
+					},
+				},
+			},
+		}
+
+		qb = QueryBuilder{Queryer: &db}
+
+		ids   []int64
+		kinds []string
+	)
+
+	cur, err := qb.PrepareCompound(
+		CompoundStatement{
+			Operator: UnionAll,
+			Statements: []SelectStatement{
+				feedStatement("posts", Eq(Column("author_id"))),
+				feedStatement("comments", Eq(Column("author_id"))),
+			},
+		},
+	).Query(ctx, map[string]interface{}{"author_id": 3})
+
+	assert.NoError(t, err)
+
+	err = ScrollCursor(cur, func(sc Scanner) error {
+		var (
+			id   int64
+			kind string
+		)
+
+		if err := sc.Scan(map[string]interface{}{"id": &id, "kind": &kind}); err != nil {
+			return err
+		}
+
+		ids = append(ids, id)
+		kinds = append(kinds, kind)
+
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{1, 7}, ids)
+	assert.Equal(t, []string{"posts", "comments"}, kinds)
+
+	db.QueryQueries[0].Assert(
+		t,
+		"SELECT \"posts\".\"id\", 'posts' AS kind FROM posts WHERE author_id = $1 UNION ALL SELECT \"comments\".\"id\", 'comments' AS kind FROM comments WHERE author_id = $2",
+		3,
+		3,
+	)
+
+	err = qb.PrepareCompound(
+		CompoundStatement{
+			Statements: []SelectStatement{
+				feedStatement("posts", nil),
+				{Table: "users", SelectClauses: []Marker{Column("id")}},
+			},
+		},
+	).QueryRow(ctx, nil).Scan(map[string]interface{}{"id": new(int64)})
+
+	assert.Equal(
+		t,
+		ErrBindingsMismatch{
+			Statement: 1,
+			Bindings:  []string{"id"},
+			Expected:  []string{"id", "kind"},
+		},
+		err,
+	)
+	assert.Len(t, db.QueryRowQueries, 0)
+}
@@ -20,13 +20,23 @@ const (
 
 type JoinClause struct {
 	Table string
-	Type  JoinType
+	// DerivedTable, when set, is joined instead of Table.
+	DerivedTable *Subquery
+	Type         JoinType
 
 	WhereClause PredicateClause
 }
 
 func (jc JoinClause) WriteTo(w QueryWriter, vs map[string]interface{}) error {
-	fmt.Fprintf(w, " %s JOIN %s", strings.ToUpper(string(jc.Type)), jc.Table)
+	fmt.Fprintf(w, " %s JOIN ", strings.ToUpper(string(jc.Type)))
+
+	if dt := jc.DerivedTable; dt != nil {
+		if err := dt.WriteTo(w, vs); err != nil {
+			return err
+		}
+	} else {
+		io.WriteString(w, jc.Table)
+	}
 
 	if jc.WhereClause == nil {
 		return errEmptyWhereClause
@@ -45,9 +55,10 @@ func cloneJoinClauses(jcs []JoinClause) []JoinClause {
 
 	for i, jc := range jcs {
 		res[i] = JoinClause{
-			Table:       jc.Table,
-			Type:        jc.Type,
-			WhereClause: jc.WhereClause.Clone(),
+			Table:        jc.Table,
+			DerivedTable: jc.DerivedTable.clone(),
+			Type:         jc.Type,
+			WhereClause:  clonePredicateClause(jc.WhereClause),
 		}
 	}
 
@@ -17,6 +17,12 @@ func (qb *QueryBuilder) PrepareSelect(ss SelectStatement) *SelectQueryer {
 	return &SelectQueryer{QueryBuilder: qb, Statement: ss}
 }
 
+// PrepareCompound returns a Queryer combining the rows of the statements,
+// they have to select the same bindings.
+func (qb *QueryBuilder) PrepareCompound(cs CompoundStatement) *CompoundQueryer {
+	return &CompoundQueryer{QueryBuilder: qb, Statement: cs}
+}
+
 func (qb *QueryBuilder) PrepareInsert(is InsertStatement) *InsertExecer {
 	return &InsertExecer{
 		execer:       execer{qb: qb, stmt: is},
@@ -97,19 +103,18 @@ type DeleteExecer struct {
 	Statement    DeleteStatement
 }
 
-type SelectQueryer struct {
-	QueryBuilder *QueryBuilder
-	Statement    SelectStatement
+type queryStatement interface {
+	buildQuery(map[string]interface{}) (string, []interface{}, []string, error)
 }
 
-func (sq *SelectQueryer) Query(ctx context.Context, qvs map[string]interface{}) (Cursor, error) {
-	stmt, vs, ks, err := sq.Statement.buildQuery(qvs)
+func query(ctx context.Context, qb *QueryBuilder, qs queryStatement, qvs map[string]interface{}) (Cursor, error) {
+	stmt, vs, ks, err := qs.buildQuery(qvs)
 
 	if err != nil {
 		return nil, err
 	}
 
-	cur, err := sq.QueryBuilder.Query(ctx, stmt, vs...)
+	cur, err := qb.Query(ctx, stmt, vs...)
 
 	if err != nil {
 		return nil, err
@@ -118,14 +123,40 @@ func (sq *SelectQueryer) Query(ctx context.Context, qvs map[string]interface{})
 	return &cursor{sc: &scanner{sc: cur, ks: ks}, Cursor: cur}, nil
 }
 
-func (sq *SelectQueryer) QueryRow(ctx context.Context, qvs map[string]interface{}) Scanner {
-	stmt, vs, ks, err := sq.Statement.buildQuery(qvs)
+func queryRow(ctx context.Context, qb *QueryBuilder, qs queryStatement, qvs map[string]interface{}) Scanner {
+	stmt, vs, ks, err := qs.buildQuery(qvs)
 
 	if err != nil {
 		return ErrScanner{Err: err}
 	}
 
-	return &scanner{sc: sq.QueryBuilder.QueryRow(ctx, stmt, vs...), ks: ks}
+	return &scanner{sc: qb.QueryRow(ctx, stmt, vs...), ks: ks}
+}
+
+type SelectQueryer struct {
+	QueryBuilder *QueryBuilder
+	Statement    SelectStatement
+}
+
+func (sq *SelectQueryer) Query(ctx context.Context, qvs map[string]interface{}) (Cursor, error) {
+	return query(ctx, sq.QueryBuilder, sq.Statement, qvs)
+}
+
+func (sq *SelectQueryer) QueryRow(ctx context.Context, qvs map[string]interface{}) Scanner {
+	return queryRow(ctx, sq.QueryBuilder, sq.Statement, qvs)
+}
+
+type CompoundQueryer struct {
+	QueryBuilder *QueryBuilder
+	Statement    CompoundStatement
+}
+
+func (cq *CompoundQueryer) Query(ctx context.Context, qvs map[string]interface{}) (Cursor, error) {
+	return query(ctx, cq.QueryBuilder, cq.Statement, qvs)
+}
+
+func (cq *CompoundQueryer) QueryRow(ctx context.Context, qvs map[string]interface{}) Scanner {
+	return queryRow(ctx, cq.QueryBuilder, cq.Statement, qvs)
 }
 
 type QueryWriter interface {
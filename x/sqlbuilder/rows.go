@@ -0,0 +1,69 @@
+package sqlbuilder
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"reflect"
+
+	"github.com/upfluence/sql"
+)
+
+var errPositionalScan = errors.New("sqlbuilder: cursor scanned with positional values")
+
+// sqlCursor exposes a Cursor as a sql.Cursor, its rows are only meant to be
+// scanned through the Cursor itself.
+type sqlCursor struct {
+	Cursor
+}
+
+func (sqlCursor) Scan(...interface{}) error { return errPositionalScan }
+
+// Rows iterates over the rows of the cursor, each one being decoded by fn,
+// following the semantics of sql.Rows.
+func Rows[T any](c Cursor, fn func(Scanner) (T, error)) iter.Seq2[T, error] {
+	return sql.Rows(
+		sqlCursor{Cursor: c},
+		func(sql.Scanner) (T, error) { return fn(c) },
+	)
+}
+
+// ScanStructAs scans the row into a new T through the db tags of its fields,
+// T is either a struct or a pointer to a struct.
+func ScanStructAs[T any](sc Scanner) (T, error) {
+	var v T
+
+	if rv := reflect.ValueOf(&v).Elem(); rv.Kind() == reflect.Pointer {
+		rv.Set(reflect.New(rv.Type().Elem()))
+
+		return v, ScanStruct(sc, rv.Interface())
+	}
+
+	return v, ScanStruct(sc, &v)
+}
+
+// QueryAll executes the query and scans all the returned rows into structs.
+func QueryAll[T any](ctx context.Context, q Queryer, qvs map[string]interface{}) ([]T, error) {
+	cur, err := q.Query(ctx, qvs)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var res []T
+
+	for v, err := range Rows(cur, ScanStructAs[T]) {
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, v)
+	}
+
+	return res, nil
+}
+
+// QueryOne executes the query and scans its first row into a struct.
+func QueryOne[T any](ctx context.Context, q Queryer, qvs map[string]interface{}) (T, error) {
+	return ScanStructAs[T](q.QueryRow(ctx, qvs))
+}
@@ -0,0 +1,106 @@
+package sqlbuilder
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/upfluence/sql"
+	"github.com/upfluence/sql/backend/static"
+)
+
+func usersQueryer(db *static.DB) *SelectQueryer {
+	return (&QueryBuilder{Queryer: db}).PrepareSelect(
+		SelectStatement{
+			Table:         "users",
+			SelectClauses: StructMarkers(user{}),
+			WhereClause:   Eq(Column("source")),
+		},
+	)
+}
+
+func usersDB() *static.DB {
+	return &static.DB{
+		Queryer: static.Queryer{
+			QueryScanner: &static.MultipleCursor{
+				Scanners: []static.Scanner{
+					userScanner(1, "bob"),
+					userScanner(2, "alice"),
+				},
+			},
+			QueryRowScanner: userScanner(3, "carol"),
+		},
+	}
+}
+
+func TestQueryAll(t *testing.T) {
+	var (
+		ctx = context.Background()
+		qvs = map[string]interface{}{"source": "web"}
+	)
+
+	us, err := QueryAll[user](ctx, usersQueryer(usersDB()), qvs)
+
+	assert.NoError(t, err)
+	assert.Len(t, us, 2)
+	assert.Equal(t, int64(2), us[1].ID)
+	assert.Equal(t, "alice", *us[1].Name)
+
+	pus, err := QueryAll[*user](ctx, usersQueryer(usersDB()), qvs)
+
+	assert.NoError(t, err)
+	assert.Len(t, pus, 2)
+	assert.Equal(t, int64(1), pus[0].ID)
+	assert.Equal(t, "web", pus[0].Source)
+
+	_, err = QueryAll[user](ctx, usersQueryer(usersDB()), nil)
+	assert.Equal(t, ErrMissingKey{Key: "source"}, err)
+}
+
+func TestQueryOne(t *testing.T) {
+	var (
+		ctx = context.Background()
+		db  = usersDB()
+	)
+
+	u, err := QueryOne[*user](
+		ctx,
+		usersQueryer(db),
+		map[string]interface{}{"source": "web"},
+	)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), u.ID)
+
+	db.QueryRowScanner = static.Scanner{Err: sql.ErrNoRows}
+
+	_, err = QueryOne[user](
+		ctx,
+		usersQueryer(db),
+		map[string]interface{}{"source": "web"},
+	)
+	assert.Equal(t, sql.ErrNoRows, err)
+}
+
+func TestRowsBreak(t *testing.T) {
+	var (
+		ids []int64
+
+		cur, err = usersQueryer(usersDB()).Query(
+			context.Background(),
+			map[string]interface{}{"source": "web"},
+		)
+	)
+
+	assert.NoError(t, err)
+
+	for u, err := range Rows(cur, ScanStructAs[user]) {
+		assert.NoError(t, err)
+
+		ids = append(ids, u.ID)
+		break
+	}
+
+	assert.Equal(t, []int64{1}, ids)
+}
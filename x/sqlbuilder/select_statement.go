@@ -2,6 +2,7 @@ package sqlbuilder
 
 import (
 	"fmt"
+	"io"
 
 	"github.com/upfluence/sql"
 )
@@ -12,7 +13,12 @@ type NullableInt struct {
 }
 
 type SelectStatement struct {
+	// With holds the common table expressions the statement can read from.
+	With []CommonTableExpression
+
 	Table string
+	// DerivedTable, when set, is read from instead of Table.
+	DerivedTable *Subquery
 
 	JoinClauses    []JoinClause
 	OrderByClauses []OrderByClause
@@ -29,7 +35,9 @@ type SelectStatement struct {
 
 func (ss SelectStatement) Clone() SelectStatement {
 	return SelectStatement{
+		With:           cloneCommonTableExpressions(ss.With),
 		Table:          ss.Table,
+		DerivedTable:   ss.DerivedTable.clone(),
 		JoinClauses:    cloneJoinClauses(ss.JoinClauses),
 		OrderByClauses: cloneOrderByClauses(ss.OrderByClauses),
 		SelectClauses:  cloneMarkers(ss.SelectClauses),
@@ -42,97 +50,121 @@ func (ss SelectStatement) Clone() SelectStatement {
 	}
 }
 
-func writeSelectClause(c Marker, qw *queryWriter, vs map[string]interface{}) error {
+func writeSelectClause(c Marker, qw QueryWriter, vs map[string]interface{}) error {
 	if qs, ok := c.(QuerySegment); ok {
 		return qs.WriteTo(qw, vs)
 	}
 
-	qw.WriteString(c.ToSQL())
-	return nil
+	_, err := io.WriteString(qw, c.ToSQL())
+	return err
 }
 
 func (ss SelectStatement) buildQuery(vs map[string]interface{}) (string, []interface{}, []string, error) {
-	var (
-		qw       queryWriter
-		bindings []string
-	)
+	var qw queryWriter
+
+	bindings, err := ss.writeTo(&qw, vs)
+
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	if ss.Consistency != sql.EventuallyConsistent {
+		qw.vs = append(qw.vs, ss.Consistency)
+	}
+
+	return qw.String(), qw.vs, bindings, nil
+}
+
+// writeTo writes the statement, the variables are redeemed through the
+// writer so the statement can be nested in another one.
+func (ss SelectStatement) writeTo(qw QueryWriter, vs map[string]interface{}) ([]string, error) {
+	var bindings []string
 
 	if len(ss.SelectClauses) == 0 {
-		return "", nil, nil, errNoMarkers
+		return nil, errNoMarkers
 	}
 
-	qw.WriteString("SELECT ")
+	if err := writeCommonTableExpressions(ss.With, qw, vs); err != nil {
+		return nil, err
+	}
+
+	io.WriteString(qw, "SELECT ")
 
 	for i, c := range ss.SelectClauses {
-		if err := writeSelectClause(c, &qw, vs); err != nil {
-			return "", nil, nil, err
+		if err := writeSelectClause(c, qw, vs); err != nil {
+			return nil, err
 		}
 
 		if i < len(ss.SelectClauses)-1 {
-			qw.WriteString(", ")
+			io.WriteString(qw, ", ")
 		}
 
 		bindings = append(bindings, c.Binding())
 	}
 
-	qw.WriteString(" FROM ")
-	qw.WriteString(ss.Table)
+	io.WriteString(qw, " FROM ")
+
+	if dt := ss.DerivedTable; dt != nil {
+		if err := dt.WriteTo(qw, vs); err != nil {
+			return nil, err
+		}
+	} else {
+		io.WriteString(qw, ss.Table)
+	}
 
 	for _, jc := range ss.JoinClauses {
-		jc.WriteTo(&qw, vs)
+		if err := jc.WriteTo(qw, vs); err != nil {
+			return nil, err
+		}
 	}
 
 	if wc := ss.WhereClause; wc != nil {
-		qw.WriteString(" WHERE ")
+		io.WriteString(qw, " WHERE ")
 
-		if err := wc.WriteTo(&qw, vs); err != nil {
-			return "", nil, nil, err
+		if err := wc.WriteTo(qw, vs); err != nil {
+			return nil, err
 		}
 	}
 
 	if len(ss.GroupByClause) > 0 {
-		qw.WriteString(" GROUP BY ")
+		io.WriteString(qw, " GROUP BY ")
 
 		for i, c := range ss.GroupByClause {
-			qw.WriteString(c.ToSQL())
+			io.WriteString(qw, c.ToSQL())
 
 			if i < len(ss.GroupByClause)-1 {
-				qw.WriteString(", ")
+				io.WriteString(qw, ", ")
 			}
 		}
 	}
 
 	if hc := ss.HavingClause; hc != nil {
-		qw.WriteString(" HAVING ")
+		io.WriteString(qw, " HAVING ")
 
-		if err := hc.WriteTo(&qw, vs); err != nil {
-			return "", nil, nil, err
+		if err := hc.WriteTo(qw, vs); err != nil {
+			return nil, err
 		}
 	}
 
 	if len(ss.OrderByClauses) > 0 {
-		qw.WriteString(" ORDER BY ")
+		io.WriteString(qw, " ORDER BY ")
 
 		for i, c := range ss.OrderByClauses {
-			qw.WriteString(c.ToSQL())
+			io.WriteString(qw, c.ToSQL())
 
 			if i < len(ss.OrderByClauses)-1 {
-				qw.WriteString(", ")
+				io.WriteString(qw, ", ")
 			}
 		}
 	}
 
 	if ss.Limit.Valid {
-		fmt.Fprintf(&qw, " LIMIT %d", ss.Limit.Int)
+		fmt.Fprintf(qw, " LIMIT %d", ss.Limit.Int)
 	}
 
 	if ss.Offset.Valid {
-		fmt.Fprintf(&qw, " OFFSET %d", ss.Offset.Int)
+		fmt.Fprintf(qw, " OFFSET %d", ss.Offset.Int)
 	}
 
-	if ss.Consistency != sql.EventuallyConsistent {
-		qw.vs = append(qw.vs, ss.Consistency)
-	}
-
-	return qw.String(), qw.vs, bindings, nil
+	return bindings, nil
 }
@@ -344,6 +344,197 @@ func TestSelectQuery(t *testing.T) {
 			vs:   map[string]interface{}{"bar_baz": "qux"},
 			args: []interface{}{"qux"},
 		},
+		{
+			name: "common table expression",
+			ss: SelectStatement{
+				With: []CommonTableExpression{
+					{
+						Name: "active_users",
+						Statement: SelectStatement{
+							Table:         "users",
+							SelectClauses: []Marker{Column("id")},
+							WhereClause:   Eq(Column("state")),
+						},
+					},
+				},
+				Table:         "active_users",
+				SelectClauses: []Marker{Column("id")},
+				WhereClause:   Gt(Column("id")),
+			},
+			vs:   map[string]interface{}{"state": "active", "id": 12},
+			stmt: "WITH active_users AS (SELECT id FROM users WHERE state = $1) SELECT id FROM active_users WHERE id > $2",
+			args: []interface{}{"active", 12},
+		},
+		{
+			name: "recursive common table expression",
+			ss: SelectStatement{
+				With: []CommonTableExpression{
+					{
+						Name:    "tree",
+						Columns: []string{"id", "parent_id"},
+						Statement: SelectStatement{
+							Table:         "nodes",
+							SelectClauses: []Marker{Column("id"), Column("parent_id")},
+							WhereClause:   Eq(Column("id")),
+						},
+						RecursiveStatement: &SelectStatement{
+							Table: "nodes",
+							SelectClauses: []Marker{
+								ColumnWithTable("id", "nodes", "id"),
+								ColumnWithTable("parent_id", "nodes", "parent_id"),
+							},
+							JoinClauses: []JoinClause{
+								{
+									Table: "tree",
+									WhereClause: EqMarkers(
+										ColumnWithTable("", "tree", "id"),
+										ColumnWithTable("", "nodes", "parent_id"),
+									),
+								},
+							},
+						},
+					},
+				},
+				Table:         "tree",
+				SelectClauses: []Marker{Column("id")},
+				Limit:         NullableInt{Int: 10, Valid: true},
+			},
+			vs:   map[string]interface{}{"id": 1},
+			stmt: "WITH RECURSIVE tree(id, parent_id) AS (SELECT id, parent_id FROM nodes WHERE id = $1 UNION ALL SELECT \"nodes\".\"id\", \"nodes\".\"parent_id\" FROM nodes  JOIN tree ON \"tree\".\"id\" = \"nodes\".\"parent_id\") SELECT id FROM tree LIMIT 10",
+			args: []interface{}{1},
+		},
+		{
+			name: "derived table",
+			ss: SelectStatement{
+				DerivedTable: &Subquery{
+					Alias: "recent",
+					Statement: SelectStatement{
+						Table:         "posts",
+						SelectClauses: []Marker{Column("author_id")},
+						WhereClause:   StaticGt(Column("created_at"), "2024-01-01"),
+					},
+				},
+				SelectClauses: []Marker{Column("author_id"), SQLExpression("count", "COUNT(*)")},
+				GroupByClause: []Marker{Column("author_id")},
+				HavingClause:  Gt(SQLExpression("count", "COUNT(*)")),
+			},
+			vs:   map[string]interface{}{"count": 3},
+			stmt: "SELECT author_id, COUNT(*) FROM (SELECT author_id FROM posts WHERE created_at > $1) AS recent GROUP BY author_id HAVING COUNT(*) > $2",
+			args: []interface{}{"2024-01-01", 3},
+		},
+		{
+			name: "join derived table",
+			ss: SelectStatement{
+				Table:         "users",
+				SelectClauses: []Marker{Column("name"), ColumnWithTable("total", "totals", "total")},
+				JoinClauses: []JoinClause{
+					{
+						Type: InnerJoin,
+						DerivedTable: &Subquery{
+							Alias: "totals",
+							Statement: SelectStatement{
+								Table: "orders",
+								SelectClauses: []Marker{
+									Column("user_id"),
+									SQLExpression("total", "SUM(amount) AS total"),
+								},
+								WhereClause:   Eq(Column("currency")),
+								GroupByClause: []Marker{Column("user_id")},
+							},
+						},
+						WhereClause: EqMarkers(
+							ColumnWithTable("", "totals", "user_id"),
+							ColumnWithTable("", "users", "id"),
+						),
+					},
+				},
+				WhereClause: Eq(Column("country")),
+			},
+			vs:   map[string]interface{}{"currency": "EUR", "country": "FR"},
+			stmt: "SELECT name, \"totals\".\"total\" FROM users INNER JOIN (SELECT user_id, SUM(amount) AS total FROM orders WHERE currency = $1 GROUP BY user_id) AS totals ON \"totals\".\"user_id\" = \"users\".\"id\" WHERE country = $2",
+			args: []interface{}{"EUR", "FR"},
+		},
+		{
+			name: "subquery marker",
+			ss: SelectStatement{
+				Table: "users",
+				SelectClauses: []Marker{
+					Column("id"),
+					Subquery{
+						Alias: "post_count",
+						Statement: SelectStatement{
+							Table:         "posts",
+							SelectClauses: []Marker{SQLExpression("count", "COUNT(*)")},
+							WhereClause: And(
+								EqMarkers(Column("posts.author_id"), Column("users.id")),
+								Eq(Column("state")),
+							),
+						},
+					},
+				},
+				WhereClause:    Eq(Column("country")),
+				OrderByClauses: []OrderByClause{{Field: Subquery{Alias: "post_count"}, Direction: Desc}},
+			},
+			vs:   map[string]interface{}{"state": "published", "country": "FR"},
+			stmt: "SELECT id, (SELECT COUNT(*) FROM posts WHERE (posts.author_id = users.id) AND (state = $1)) AS post_count FROM users WHERE country = $2 ORDER BY post_count DESC",
+			args: []interface{}{"published", "FR"},
+		},
+		{
+			name: "in subquery",
+			ss: SelectStatement{
+				Table:         "users",
+				SelectClauses: []Marker{Column("id")},
+				WhereClause: And(
+					Eq(Column("country")),
+					InSubquery(
+						Column("id"),
+						SelectStatement{
+							Table:         "posts",
+							SelectClauses: []Marker{Column("author_id")},
+							WhereClause:   In(Column("tag")),
+						},
+					),
+					Not(
+						GteSubquery(
+							Column("age"),
+							SelectStatement{
+								Table:         "limits",
+								SelectClauses: []Marker{Column("age")},
+								WhereClause:   StaticEq(Column("kind"), "max"),
+							},
+						),
+					),
+				),
+			},
+			vs:   map[string]interface{}{"country": "FR", "tag": []string{"go", "sql"}},
+			stmt: "SELECT id FROM users WHERE (country = $1) AND (id IN (SELECT author_id FROM posts WHERE tag IN ($2, $3))) AND (NOT (age >= (SELECT age FROM limits WHERE kind = $4)))",
+			args: []interface{}{"FR", "go", "sql", "max"},
+		},
+		{
+			name: "subquery error",
+			ss: SelectStatement{
+				Table:         "users",
+				SelectClauses: []Marker{Column("id")},
+				WhereClause: EqSubquery(
+					Column("id"),
+					SelectStatement{
+						Table:         "posts",
+						SelectClauses: []Marker{Column("author_id")},
+						WhereClause:   Eq(Column("slug")),
+					},
+				),
+			},
+			err: ErrMissingKey{Key: "slug"},
+		},
+		{
+			name: "join error",
+			ss: SelectStatement{
+				Table:         "users",
+				SelectClauses: []Marker{Column("id")},
+				JoinClauses:   []JoinClause{{Table: "posts"}},
+			},
+			err: errEmptyWhereClause,
+		},
 	} {
 		t.Run(tt.name, func(t *testing.T) {
 			stmt, args, _, err := tt.ss.Clone().buildQuery(tt.vs)
@@ -0,0 +1,292 @@
+package sqlbuilder
+
+import (
+	stdsql "database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+const structTag = "db"
+
+var (
+	scannerType = reflect.TypeOf((*stdsql.Scanner)(nil)).Elem()
+	valuerType  = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+
+	structPlans sync.Map
+)
+
+type ErrInvalidStructType struct{ Type reflect.Type }
+
+func (eist ErrInvalidStructType) Error() string {
+	return fmt.Sprintf("%v is not a struct", eist.Type)
+}
+
+type ErrDuplicatedColumn struct {
+	Type   reflect.Type
+	Column string
+}
+
+func (edc ErrDuplicatedColumn) Error() string {
+	return fmt.Sprintf("%q column defined twice in %v", edc.Column, edc.Type)
+}
+
+type structField struct {
+	column string
+	index  []int
+}
+
+type structPlan struct {
+	fields []structField
+	err    error
+}
+
+// isValue reports whether the type is mapped to a single column rather than
+// flattened when it is embedded.
+func isValue(t reflect.Type) bool {
+	return t.Implements(valuerType) ||
+		t.Implements(scannerType) ||
+		reflect.PointerTo(t).Implements(scannerType)
+}
+
+func buildStructPlan(t reflect.Type) structPlan {
+	var (
+		fields []structField
+		depths = make(map[string]int)
+		err    error
+	)
+
+	var walk func(reflect.Type, []int)
+
+	walk = func(st reflect.Type, index []int) {
+		for i := 0; i < st.NumField(); i++ {
+			f := st.Field(i)
+			tag, tagged := f.Tag.Lookup(structTag)
+
+			if tag == "-" {
+				continue
+			}
+
+			fi := append(append([]int(nil), index...), i)
+
+			if !tagged {
+				ft := f.Type
+
+				if ft.Kind() == reflect.Pointer {
+					if !f.IsExported() {
+						// The embedded pointer could not be allocated on scan.
+						continue
+					}
+
+					ft = ft.Elem()
+				}
+
+				if f.Anonymous && ft.Kind() == reflect.Struct && !isValue(ft) {
+					walk(ft, fi)
+				}
+
+				continue
+			}
+
+			if !f.IsExported() {
+				continue
+			}
+
+			d, ok := depths[tag]
+
+			switch {
+			case !ok:
+				depths[tag] = len(fi)
+				fields = append(fields, structField{column: tag, index: fi})
+			case d == len(fi):
+				err = ErrDuplicatedColumn{Type: t, Column: tag}
+			case d > len(fi):
+				depths[tag] = len(fi)
+
+				for j := range fields {
+					if fields[j].column == tag {
+						fields[j].index = fi
+					}
+				}
+			}
+		}
+	}
+
+	walk(t, nil)
+
+	return structPlan{fields: fields, err: err}
+}
+
+func structPlanOf(t reflect.Type) (structPlan, error) {
+	if t.Kind() != reflect.Struct {
+		return structPlan{}, ErrInvalidStructType{Type: t}
+	}
+
+	if p, ok := structPlans.Load(t); ok {
+		return p.(structPlan), p.(structPlan).err
+	}
+
+	p, _ := structPlans.LoadOrStore(t, buildStructPlan(t))
+
+	return p.(structPlan), p.(structPlan).err
+}
+
+func indirectType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	return t
+}
+
+// StructMarkers returns the column markers of the fields of the struct
+// tagged with `db:"column"`, in their declaration order. The fields of the
+// embedded structs are included unless they implement sql.Scanner or
+// driver.Valuer in which case they need to be tagged as a single column.
+// It panics if v is not a struct or a pointer to a struct.
+func StructMarkers(v interface{}) []Marker {
+	p, err := structPlanOf(indirectType(reflect.TypeOf(v)))
+
+	if err != nil {
+		panic(err)
+	}
+
+	ms := make([]Marker, len(p.fields))
+
+	for i, f := range p.fields {
+		ms[i] = Column(f.column)
+	}
+
+	return ms
+}
+
+// StructValues returns the values of the tagged fields of the struct keyed
+// by their column, to be given to the Execer of an insert or an update.
+// The fields of a nil embedded pointer are mapped to nil.
+func StructValues(v interface{}) (map[string]interface{}, error) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+
+	if !rv.IsValid() {
+		return nil, ErrInvalidStructType{Type: reflect.TypeOf(v)}
+	}
+
+	p, err := structPlanOf(rv.Type())
+
+	if err != nil {
+		return nil, err
+	}
+
+	vs := make(map[string]interface{}, len(p.fields))
+
+	for _, f := range p.fields {
+		vs[f.column] = nil
+
+		if fv, ok := fieldByIndex(rv, f.index, false); ok {
+			vs[f.column] = fv.Interface()
+		}
+	}
+
+	return vs, nil
+}
+
+func fieldByIndex(v reflect.Value, index []int, alloc bool) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !alloc {
+					return reflect.Value{}, false
+				}
+
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+
+			v = v.Elem()
+		}
+
+		v = v.Field(x)
+	}
+
+	return v, true
+}
+
+func structTargets(dst interface{}) (map[string]interface{}, error) {
+	rv := reflect.ValueOf(dst)
+
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return nil, ErrInvalidStructType{Type: reflect.TypeOf(dst)}
+	}
+
+	rv = rv.Elem()
+
+	p, err := structPlanOf(rv.Type())
+
+	if err != nil {
+		return nil, err
+	}
+
+	vs := make(map[string]interface{}, len(p.fields))
+
+	for _, f := range p.fields {
+		fv, _ := fieldByIndex(rv, f.index, true)
+		vs[f.column] = fv.Addr().Interface()
+	}
+
+	return vs, nil
+}
+
+// ScanStruct scans the row into the struct pointed by dst, the selected
+// markers are matched against the tags of its fields.
+func ScanStruct(sc Scanner, dst interface{}) error {
+	vs, err := structTargets(dst)
+
+	if err != nil {
+		return err
+	}
+
+	return sc.Scan(vs)
+}
+
+// ScanStructs scans every row of the cursor into the slice pointed by dst,
+// its elements are either structs or pointers to structs. The cursor is
+// closed once consumed.
+func ScanStructs(c Cursor, dst interface{}) error {
+	rv := reflect.ValueOf(dst)
+
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Slice {
+		c.Close()
+		return ErrInvalidStructType{Type: reflect.TypeOf(dst)}
+	}
+
+	var (
+		sv = rv.Elem()
+		et = sv.Type().Elem()
+
+		isPtr = et.Kind() == reflect.Pointer
+	)
+
+	if isPtr {
+		et = et.Elem()
+	}
+
+	if _, err := structPlanOf(et); err != nil {
+		c.Close()
+		return err
+	}
+
+	return ScrollCursor(c, func(sc Scanner) error {
+		ev := reflect.New(et)
+
+		if err := ScanStruct(sc, ev.Interface()); err != nil {
+			return err
+		}
+
+		if !isPtr {
+			ev = ev.Elem()
+		}
+
+		sv.Set(reflect.Append(sv, ev))
+
+		return nil
+	})
+}
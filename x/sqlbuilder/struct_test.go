@@ -0,0 +1,300 @@
+package sqlbuilder
+
+import (
+	"context"
+	stdsql "database/sql"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/upfluence/sql"
+	"github.com/upfluence/sql/backend/static"
+	"github.com/upfluence/sql/sqltypes"
+)
+
+type timestamps struct {
+	CreatedAt int64 `db:"created_at"`
+}
+
+type Meta struct {
+	Source string `db:"source"`
+}
+
+type user struct {
+	timestamps
+	*Meta
+
+	ID      int64              `db:"id"`
+	Name    *string            `db:"name"`
+	Data    sqltypes.JSONValue `db:"data"`
+	Ignored string             `db:"-"`
+
+	internal string
+}
+
+type shadowedUser struct {
+	user
+
+	ID string `db:"id"`
+}
+
+type duplicatedUser struct {
+	timestamps
+	Meta
+
+	Origin string `db:"source"`
+	Other  struct {
+		Source string `db:"source"`
+	}
+}
+
+type conflictingUser struct {
+	Meta
+	otherMeta
+}
+
+type otherMeta struct {
+	Source string `db:"source"`
+}
+
+func stringPtrArg(s string) static.ScanArg {
+	return func(v interface{}) { *v.(**string) = &s }
+}
+
+func scannerArg(src interface{}) static.ScanArg {
+	return func(v interface{}) { v.(stdsql.Scanner).Scan(src) }
+}
+
+func markerBindings(ms []Marker) []string {
+	var res []string
+
+	for _, m := range ms {
+		res = append(res, m.Binding())
+	}
+
+	return res
+}
+
+func TestStructMarkers(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		in   interface{}
+		out  []string
+	}{
+		{
+			name: "embedded",
+			in:   user{},
+			out:  []string{"created_at", "source", "id", "name", "data"},
+		},
+		{
+			name: "pointer",
+			in:   &user{},
+			out:  []string{"created_at", "source", "id", "name", "data"},
+		},
+		{
+			name: "shadowed",
+			in:   shadowedUser{},
+			out:  []string{"created_at", "source", "id", "name", "data"},
+		},
+		{
+			name: "outer field prevails",
+			in:   duplicatedUser{},
+			out:  []string{"created_at", "source"},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.out, markerBindings(StructMarkers(tt.in)))
+		})
+	}
+
+	assert.Panics(t, func() { StructMarkers(12) })
+	assert.Panics(t, func() { StructMarkers(conflictingUser{}) })
+}
+
+func TestStructValues(t *testing.T) {
+	var (
+		db = static.DB{Queryer: static.Queryer{ExecResult: sql.StaticResult(1)}}
+
+		qb  = QueryBuilder{Queryer: &db}
+		ctx = context.Background()
+
+		name = "bob"
+		data = sqltypes.JSONValue{Data: []int{1}, Valid: true}
+	)
+
+	vs, err := StructValues(
+		&user{timestamps: timestamps{CreatedAt: 10}, ID: 1, Name: &name, Data: data},
+	)
+	assert.NoError(t, err)
+
+	assert.Equal(
+		t,
+		map[string]interface{}{
+			"created_at": int64(10),
+			"source":     nil,
+			"id":         int64(1),
+			"name":       &name,
+			"data":       data,
+		},
+		vs,
+	)
+
+	_, err = qb.PrepareInsert(
+		InsertStatement{Table: "users", Fields: StructMarkers(user{})},
+	).Exec(ctx, vs)
+	assert.NoError(t, err)
+
+	assert.Len(t, db.ExecQueries, 1)
+	db.ExecQueries[0].Assert(
+		t,
+		"INSERT INTO users(created_at, source, id, name, data) VALUES ($1, $2, $3, $4, $5)",
+		int64(10),
+		nil,
+		int64(1),
+		&name,
+		data,
+	)
+
+	_, err = StructValues((*user)(nil))
+	assert.Equal(t, ErrInvalidStructType{Type: reflect.TypeOf((*user)(nil))}, err)
+
+	_, err = StructValues(conflictingUser{})
+	assert.Equal(
+		t,
+		ErrDuplicatedColumn{Type: reflect.TypeOf(conflictingUser{}), Column: "source"},
+		err,
+	)
+}
+
+func userScanner(id int64, name string) static.Scanner {
+	return static.Scanner{
+		Args: []static.ScanArg{
+			static.Int64Arg(id * 10),
+			static.StringArg("web"),
+			static.Int64Arg(id),
+			stringPtrArg(name),
+			scannerArg(`{"foo":"bar"}`),
+		},
+	}
+}
+
+func TestScanStruct(t *testing.T) {
+	var (
+		db  = static.DB{Queryer: static.Queryer{QueryRowScanner: userScanner(1, "bob")}}
+		qb  = QueryBuilder{Queryer: &db}
+		ctx = context.Background()
+
+		u user
+	)
+
+	err := ScanStruct(
+		qb.PrepareSelect(
+			SelectStatement{Table: "users", SelectClauses: StructMarkers(u)},
+		).QueryRow(ctx, nil),
+		&u,
+	)
+	assert.NoError(t, err)
+
+	assert.Equal(t, int64(10), u.CreatedAt)
+	assert.Equal(t, &Meta{Source: "web"}, u.Meta)
+	assert.Equal(t, int64(1), u.ID)
+	assert.Equal(t, "bob", *u.Name)
+	assert.Equal(
+		t,
+		sqltypes.JSONValue{Data: map[string]interface{}{"foo": "bar"}, Valid: true},
+		u.Data,
+	)
+
+	db.QueryRowQueries[0].Assert(
+		t,
+		"SELECT created_at, source, id, name, data FROM users",
+	)
+
+	assert.Equal(
+		t,
+		ErrInvalidStructType{Type: reflect.TypeOf(u)},
+		ScanStruct(ErrScanner{}, u),
+	)
+	assert.Equal(t, ErrInvalidStructType{}, ScanStruct(ErrScanner{}, nil))
+}
+
+func TestScanStructs(t *testing.T) {
+	var (
+		ctx = context.Background()
+		ss  = SelectStatement{Table: "users", SelectClauses: StructMarkers(user{})}
+	)
+
+	for _, tt := range []struct {
+		name string
+		dst  interface{}
+		ids  func(interface{}) []int64
+	}{
+		{
+			name: "values",
+			dst:  &[]user{},
+			ids: func(v interface{}) []int64 {
+				var res []int64
+
+				for _, u := range *v.(*[]user) {
+					res = append(res, u.ID)
+				}
+
+				return res
+			},
+		},
+		{
+			name: "pointers",
+			dst:  &[]*user{},
+			ids: func(v interface{}) []int64 {
+				var res []int64
+
+				for _, u := range *v.(*[]*user) {
+					res = append(res, u.ID)
+				}
+
+				return res
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				db = static.DB{
+					Queryer: static.Queryer{
+						QueryScanner: &static.MultipleCursor{
+							Scanners: []static.Scanner{
+								userScanner(1, "bob"),
+								userScanner(2, "alice"),
+							},
+						},
+					},
+				}
+				qb = QueryBuilder{Queryer: &db}
+			)
+
+			cur, err := qb.PrepareSelect(ss).Query(ctx, nil)
+			assert.NoError(t, err)
+
+			assert.NoError(t, ScanStructs(cur, tt.dst))
+			assert.Equal(t, []int64{1, 2}, tt.ids(tt.dst))
+		})
+	}
+
+	for _, dst := range []interface{}{nil, []user{}, &user{}} {
+		var (
+			db = static.DB{
+				Queryer: static.Queryer{QueryScanner: &static.MultipleCursor{}},
+			}
+			qb = QueryBuilder{Queryer: &db}
+		)
+
+		cur, err := qb.PrepareSelect(ss).Query(ctx, nil)
+		assert.NoError(t, err)
+
+		assert.Equal(
+			t,
+			ErrInvalidStructType{Type: reflect.TypeOf(dst)},
+			ScanStructs(cur, dst),
+		)
+	}
+}
@@ -0,0 +1,92 @@
+package sqlbuilder
+
+import (
+	"fmt"
+	"io"
+)
+
+// Subquery nests a statement in another one under the alias. It can be read
+// from as a derived table, or selected as a Marker when the statement
+// yields a single column and row.
+type Subquery struct {
+	Alias     string
+	Statement SelectStatement
+}
+
+func (sq Subquery) Binding() string { return sq.Alias }
+func (sq Subquery) ToSQL() string   { return sq.Alias }
+func (sq Subquery) Clone() Marker   { return *sq.clone() }
+
+func (sq *Subquery) clone() *Subquery {
+	if sq == nil {
+		return nil
+	}
+
+	return &Subquery{Alias: sq.Alias, Statement: sq.Statement.Clone()}
+}
+
+func (sq Subquery) WriteTo(w QueryWriter, vs map[string]interface{}) error {
+	io.WriteString(w, "(")
+
+	if _, err := sq.Statement.writeTo(w, vs); err != nil {
+		return err
+	}
+
+	_, err := fmt.Fprintf(w, ") AS %s", sq.Alias)
+	return err
+}
+
+type subqueryClause struct {
+	m  Marker
+	op string
+	ss SelectStatement
+}
+
+func (sc *subqueryClause) Clone() PredicateClause {
+	return &subqueryClause{m: sc.m.Clone(), op: sc.op, ss: sc.ss.Clone()}
+}
+
+func (sc *subqueryClause) WriteTo(w QueryWriter, vs map[string]interface{}) error {
+	fmt.Fprintf(w, "%s %s (", sc.m.ToSQL(), sc.op)
+
+	if _, err := sc.ss.writeTo(w, vs); err != nil {
+		return err
+	}
+
+	_, err := io.WriteString(w, ")")
+	return err
+}
+
+func subqueryPredicate(m Marker, op string, ss SelectStatement) PredicateClause {
+	return &subqueryClause{m: m, op: op, ss: ss}
+}
+
+// InSubquery matches the rows whose marker is one of the values yielded by
+// the statement, its values are read from the ones of the outer statement.
+func InSubquery(m Marker, ss SelectStatement) PredicateClause {
+	return subqueryPredicate(m, "IN", ss)
+}
+
+func EqSubquery(m Marker, ss SelectStatement) PredicateClause {
+	return subqueryPredicate(m, "=", ss)
+}
+
+func NeSubquery(m Marker, ss SelectStatement) PredicateClause {
+	return subqueryPredicate(m, "!=", ss)
+}
+
+func LtSubquery(m Marker, ss SelectStatement) PredicateClause {
+	return subqueryPredicate(m, "<", ss)
+}
+
+func LteSubquery(m Marker, ss SelectStatement) PredicateClause {
+	return subqueryPredicate(m, "<=", ss)
+}
+
+func GtSubquery(m Marker, ss SelectStatement) PredicateClause {
+	return subqueryPredicate(m, ">", ss)
+}
+
+func GteSubquery(m Marker, ss SelectStatement) PredicateClause {
+	return subqueryPredicate(m, ">=", ss)
+}
@@ -0,0 +1,615 @@
+package sqlgen
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/upfluence/errors"
+)
+
+const header = "// Code generated by sqlgen. DO NOT EDIT."
+
+var (
+	ErrUnknownTable  = errors.New("sqlgen: unknown table")
+	ErrNameCollision = errors.New("sqlgen: generated identifiers sharing the same name")
+)
+
+var (
+	intTypes = map[string]struct{}{
+		"tinyint":     {},
+		"smallint":    {},
+		"mediumint":   {},
+		"int":         {},
+		"integer":     {},
+		"bigint":      {},
+		"int2":        {},
+		"int4":        {},
+		"int8":        {},
+		"smallserial": {},
+		"serial":      {},
+		"bigserial":   {},
+		"serial2":     {},
+		"serial4":     {},
+		"serial8":     {},
+	}
+
+	floatTypes = map[string]struct{}{
+		"real":   {},
+		"float":  {},
+		"float4": {},
+		"float8": {},
+		"double": {},
+	}
+
+	stringTypes = map[string]struct{}{
+		"text":       {},
+		"tinytext":   {},
+		"mediumtext": {},
+		"longtext":   {},
+		"varchar":    {},
+		"nvarchar":   {},
+		"char":       {},
+		"nchar":      {},
+		"character":  {},
+		"citext":     {},
+		"uuid":       {},
+		"clob":       {},
+		"enum":       {},
+		"numeric":    {},
+		"decimal":    {},
+	}
+
+	timeTypes = map[string]struct{}{
+		"timestamp":   {},
+		"timestamptz": {},
+		"datetime":    {},
+		"date":        {},
+		"time":        {},
+		"timetz":      {},
+	}
+
+	bytesTypes = map[string]struct{}{
+		"bytea":     {},
+		"blob":      {},
+		"tinyblob":  {},
+		"longblob":  {},
+		"binary":    {},
+		"varbinary": {},
+	}
+)
+
+const (
+	timeImport     = "time"
+	sqltypesImport = "github.com/upfluence/sql/sqltypes"
+)
+
+// goType returns the Go type of the field holding the column and the package
+// it requires to be imported.
+func goType(c *Column) (string, string) {
+	var (
+		t  = c.Type
+		pi = strings.IndexByte(t, '(')
+	)
+
+	if pi >= 0 {
+		if pe := strings.LastIndexByte(t, ')'); pe > pi {
+			t = t[:pi] + t[pe+1:]
+		}
+	}
+
+	var base string
+
+	if fs := strings.Fields(t); len(fs) > 0 {
+		base, _, _ = strings.Cut(fs[0], "[")
+	}
+
+	if strings.HasSuffix(t, "]") {
+		if _, ok := stringTypes[base]; ok {
+			return "sqltypes.StringSlice", sqltypesImport
+		}
+
+		return "interface{}", ""
+	}
+
+	var typ, imp string
+
+	switch base {
+	case "bool", "boolean":
+		typ = "bool"
+	case "json", "jsonb":
+		return "sqltypes.JSONValue", sqltypesImport
+	default:
+		if _, ok := intTypes[base]; ok {
+			typ = "int64"
+		} else if _, ok := floatTypes[base]; ok {
+			typ = "float64"
+		} else if _, ok := stringTypes[base]; ok {
+			typ = "string"
+		} else if _, ok := timeTypes[base]; ok {
+			typ, imp = "time.Time", timeImport
+		} else if _, ok := bytesTypes[base]; ok {
+			return "[]byte", ""
+		} else {
+			return "interface{}", ""
+		}
+	}
+
+	if !c.NotNull {
+		typ = "*" + typ
+	}
+
+	return typ, imp
+}
+
+type generator struct {
+	pkg    string
+	tables []string
+
+	databaseDefaults bool
+}
+
+// Option customizes the generated code.
+type Option func(*generator)
+
+// WithPackage sets the name of the package of the generated file, "models"
+// by default.
+func WithPackage(pkg string) Option {
+	return func(g *generator) { g.pkg = pkg }
+}
+
+// WithTables restricts the generation to the given tables.
+func WithTables(ts ...string) Option {
+	return func(g *generator) { g.tables = append(g.tables, ts...) }
+}
+
+// WithDatabaseDefaults leaves the columns having a default value out of the
+// inserts, they are filled by the database and read back like the generated
+// ones.
+func WithDatabaseDefaults() Option {
+	return func(g *generator) { g.databaseDefaults = true }
+}
+
+type columnData struct {
+	Name   string
+	Field  string
+	Marker string
+	Param  string
+	GoType string
+}
+
+type tableData struct {
+	Name    string
+	Struct  string
+	Columns []columnData
+
+	PrimaryKey []columnData
+	Insertable []columnData
+	Returned   []columnData
+	Updatable  []columnData
+
+	Upsertable bool
+}
+
+type fileData struct {
+	Package    string
+	StdImports []string
+	Imports    []string
+	Tables     []tableData
+}
+
+// reservedParams are the identifiers the generated methods already declare
+// next to the parameters built from the primary key columns.
+var reservedParams = map[string]struct{}{
+	"ctx": {},
+	"err": {},
+	"r":   {},
+	"v":   {},
+}
+
+// scope records the identifiers declared by the generated code along with the
+// SQL object they originate from.
+type scope map[string]string
+
+func (s scope) declare(id, origin string) error {
+	if prev, ok := s[id]; ok {
+		return errors.Wrapf(
+			ErrNameCollision,
+			"%s and %s are both named %s",
+			prev,
+			origin,
+			id,
+		)
+	}
+
+	s[id] = origin
+
+	return nil
+}
+
+func declareTable(s scope, t *Table, name string) error {
+	origin := fmt.Sprintf("table %q", t.Name)
+
+	for _, id := range []string{
+		name,
+		name + "TableName",
+		name + "Columns",
+		name + "Repository",
+		"New" + name + "Repository",
+	} {
+		if err := s.declare(id, origin); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (g *generator) selected(t *Table) bool {
+	if len(g.tables) == 0 {
+		return true
+	}
+
+	for _, n := range g.tables {
+		if n == t.Name {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (g *generator) fileData(s *Schema) (fileData, error) {
+	var (
+		fd = fileData{Package: g.pkg}
+
+		imports = map[string]struct{}{
+			"context":                                      {},
+			"github.com/upfluence/sql":                     {},
+			"github.com/upfluence/sql/x/sqlbuilder":        {},
+			"github.com/upfluence/sql/x/sqlbuilder/reader": {},
+		}
+		globals = make(scope)
+	)
+
+	for _, n := range g.tables {
+		if _, t := s.table(n); t == nil {
+			return fd, errors.Wrapf(ErrUnknownTable, "table %q", n)
+		}
+	}
+
+	for _, t := range s.Tables {
+		if !g.selected(t) {
+			continue
+		}
+
+		td := tableData{Name: t.Name, Struct: structName(t.Name)}
+
+		if err := declareTable(globals, t, td.Struct); err != nil {
+			return fd, err
+		}
+
+		fields := make(scope)
+
+		generatedPK := false
+
+		for _, c := range t.Columns {
+			typ, imp := goType(c)
+
+			if imp != "" {
+				imports[imp] = struct{}{}
+			}
+
+			cd := columnData{
+				Name:   c.Name,
+				Field:  goName(c.Name),
+				Marker: td.Struct + goName(c.Name),
+				Param:  paramName(c.Name),
+				GoType: typ,
+			}
+
+			if _, ok := reservedParams[cd.Param]; ok {
+				cd.Param = "v" + cd.Field
+			}
+
+			origin := fmt.Sprintf("column %q", t.Name+"."+c.Name)
+
+			if err := fields.declare(cd.Field, origin); err != nil {
+				return fd, err
+			}
+
+			if err := globals.declare(cd.Marker, origin); err != nil {
+				return fd, err
+			}
+
+			td.Columns = append(td.Columns, cd)
+
+			if c.PrimaryKey {
+				td.PrimaryKey = append(td.PrimaryKey, cd)
+				generatedPK = generatedPK || c.Generated
+			} else {
+				td.Updatable = append(td.Updatable, cd)
+			}
+
+			if c.Generated || (c.HasDefault && g.databaseDefaults) {
+				td.Returned = append(td.Returned, cd)
+			} else {
+				td.Insertable = append(td.Insertable, cd)
+			}
+		}
+
+		if len(td.PrimaryKey) > 0 && !generatedPK {
+			td.Upsertable = true
+			imports["github.com/upfluence/sql/x/sqlbuilder/upserter"] = struct{}{}
+		}
+
+		fd.Tables = append(fd.Tables, td)
+	}
+
+	for imp := range imports {
+		if strings.Contains(imp, ".") {
+			fd.Imports = append(fd.Imports, imp)
+		} else {
+			fd.StdImports = append(fd.StdImports, imp)
+		}
+	}
+
+	sort.Strings(fd.StdImports)
+	sort.Strings(fd.Imports)
+
+	return fd, nil
+}
+
+// Generate returns the Go source of the structs, markers and repositories
+// of the tables of the schema.
+func Generate(s *Schema, opts ...Option) ([]byte, error) {
+	g := generator{pkg: "models"}
+
+	for _, opt := range opts {
+		opt(&g)
+	}
+
+	fd, err := g.fileData(s)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+
+	if err := fileTemplate.Execute(&buf, fd); err != nil {
+		return nil, err
+	}
+
+	res, err := format.Source(buf.Bytes())
+
+	if err != nil {
+		return nil, errors.Wrap(err, "sqlgen: invalid generated code")
+	}
+
+	return res, nil
+}
+
+func markerList(cs []columnData) string {
+	ms := make([]string, len(cs))
+
+	for i, c := range cs {
+		ms[i] = c.Marker
+	}
+
+	return strings.Join(ms, ", ")
+}
+
+func whereClause(cs []columnData) string {
+	ps := make([]string, len(cs))
+
+	for i, c := range cs {
+		ps[i] = "sqlbuilder.Eq(" + c.Marker + ")"
+	}
+
+	if len(ps) == 1 {
+		return ps[0]
+	}
+
+	return "sqlbuilder.And(" + strings.Join(ps, ", ") + ")"
+}
+
+var fileTemplate = template.Must(
+	template.New("file").Funcs(
+		template.FuncMap{
+			"quote":       strconv.Quote,
+			"markers":     markerList,
+			"whereClause": whereClause,
+		},
+	).Parse(fileTemplateText),
+)
+
+const fileTemplateText = header + `
+
+package {{.Package}}
+
+import (
+{{- range .StdImports}}
+	{{quote .}}
+{{- end}}
+{{range .Imports}}
+	{{quote .}}
+{{- end}}
+)
+{{range .Tables}}
+// {{.Struct}}TableName is the name of the table holding the {{.Struct}} rows.
+const {{.Struct}}TableName = {{quote .Name}}
+
+var (
+{{- range .Columns}}
+	{{.Marker}} = sqlbuilder.Column({{quote .Name}})
+{{- end}}
+
+	{{.Struct}}Columns = []sqlbuilder.Marker{ {{- markers .Columns -}} }
+)
+
+// {{.Struct}} is a row of the {{.Name}} table.
+type {{.Struct}} struct {
+{{- range .Columns}}
+	{{.Field}} {{.GoType}} ` + "`" + `db:{{quote .Name}}` + "`" + `
+{{- end}}
+}
+
+// {{.Struct}}Repository reads and writes the rows of the {{.Name}} table.
+type {{.Struct}}Repository struct {
+	DB sql.DB
+}
+
+func New{{.Struct}}Repository(db sql.DB) *{{.Struct}}Repository {
+	return &{{.Struct}}Repository{DB: db}
+}
+
+// Reader returns a reader over the {{.Name}} table, its predicates have to
+// be built with the Static predicate clauses.
+func (r *{{.Struct}}Repository) Reader() reader.Reader {
+	return reader.RootReader(r.DB, {{.Struct}}TableName)
+}
+
+// List returns the rows matching all the given predicates.
+func (r *{{.Struct}}Repository) List(ctx context.Context, pcs ...sqlbuilder.PredicateClause) ([]*{{.Struct}}, error) {
+	cur, err := r.Reader().WithPredicateClauses(pcs...).Read(
+		ctx,
+		reader.ReadOptions{SelectClauses: {{.Struct}}Columns},
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var res []*{{.Struct}}
+
+	if err := sqlbuilder.ScanStructs(cur, &res); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+{{if .PrimaryKey}}
+// Get returns the row identified by the primary key, sql.ErrNoRows is
+// returned when there is none.
+func (r *{{.Struct}}Repository) Get(ctx context.Context{{range .PrimaryKey}}, {{.Param}} {{.GoType}}{{end}}) (*{{.Struct}}, error) {
+	var v {{.Struct}}
+
+	if err := sqlbuilder.ScanStruct(
+		r.Reader().WithPredicateClauses(
+		{{- range .PrimaryKey}}
+			sqlbuilder.StaticEq({{.Marker}}, {{.Param}}),
+		{{- end}}
+		).ReadOne(ctx, reader.ReadOptions{SelectClauses: {{.Struct}}Columns}),
+		&v,
+	); err != nil {
+		return nil, err
+	}
+
+	return &v, nil
+}
+{{end}}
+{{- if .Insertable}}
+// Insert adds the row to the table
+{{- if .Returned}}, the columns filled by the database
+// are read back into v{{end}}.
+func (r *{{.Struct}}Repository) Insert(ctx context.Context, v *{{.Struct}}) error {
+	vs, err := sqlbuilder.StructValues(v)
+
+	if err != nil {
+		return err
+	}
+{{if .Returned}}
+	return (&sqlbuilder.QueryBuilder{Queryer: r.DB}).PrepareInsert(
+		sqlbuilder.InsertStatement{
+			Table:  {{.Struct}}TableName,
+			Fields: []sqlbuilder.Marker{ {{- markers .Insertable -}} },
+			Returnings: []*sql.Returning{
+			{{- range .Returned}}
+				{Field: {{quote .Name}}},
+			{{- end}}
+			},
+		},
+	).QueryRow(ctx, vs).Scan(
+	{{- range .Returned}}
+		&v.{{.Field}},
+	{{- end}}
+	)
+{{- else}}
+	_, err = (&sqlbuilder.QueryBuilder{Queryer: r.DB}).PrepareInsert(
+		sqlbuilder.InsertStatement{
+			Table:  {{.Struct}}TableName,
+			Fields: []sqlbuilder.Marker{ {{- markers .Insertable -}} },
+		},
+	).Exec(ctx, vs)
+
+	return err
+{{- end}}
+}
+{{end}}
+{{- if and .PrimaryKey .Updatable}}
+// Update writes the columns of v to the row sharing its primary key.
+func (r *{{.Struct}}Repository) Update(ctx context.Context, v *{{.Struct}}) error {
+	vs, err := sqlbuilder.StructValues(v)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = (&sqlbuilder.QueryBuilder{Queryer: r.DB}).PrepareUpdate(
+		sqlbuilder.UpdateStatement{
+			Table:       {{.Struct}}TableName,
+			Fields:      []sqlbuilder.Marker{ {{- markers .Updatable -}} },
+			WhereClause: {{whereClause .PrimaryKey}},
+		},
+	).Exec(ctx, vs)
+
+	return err
+}
+{{end}}
+{{- if .Upsertable}}
+// Upsert inserts the row, or updates the one sharing its primary key.
+func (r *{{.Struct}}Repository) Upsert(ctx context.Context, v *{{.Struct}}) error {
+	vs, err := sqlbuilder.StructValues(v)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = (&upserter.Upserter{DB: r.DB}).PrepareUpsert(
+		upserter.Statement{
+			Table:            {{.Struct}}TableName,
+			QueryValues:      []sqlbuilder.Marker{ {{- markers .PrimaryKey -}} },
+			SetValues:        []sqlbuilder.Marker{ {{- markers .Updatable -}} },
+			QueryConstrained: true,
+		},
+	).Exec(ctx, vs)
+
+	return err
+}
+{{end}}
+{{- if .PrimaryKey}}
+// Delete removes the row identified by the primary key.
+func (r *{{.Struct}}Repository) Delete(ctx context.Context{{range .PrimaryKey}}, {{.Param}} {{.GoType}}{{end}}) error {
+	_, err := (&sqlbuilder.QueryBuilder{Queryer: r.DB}).PrepareDelete(
+		sqlbuilder.DeleteStatement{
+			Table:       {{.Struct}}TableName,
+			WhereClause: {{whereClause .PrimaryKey}},
+		},
+	).Exec(
+		ctx,
+		map[string]interface{}{
+		{{- range .PrimaryKey}}
+			{{.Marker}}.Binding(): {{.Param}},
+		{{- end}}
+		},
+	)
+
+	return err
+}
+{{end}}
+{{- end}}`
@@ -0,0 +1,209 @@
+package sqlgen
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/upfluence/errors"
+)
+
+func testSchema(t *testing.T) *Schema {
+	var s Schema
+
+	err := s.Apply(`
+		CREATE TABLE users (
+			id serial PRIMARY KEY,
+			email text NOT NULL,
+			name text,
+			payload jsonb,
+			tags varchar(32)[],
+			created_at timestamp NOT NULL DEFAULT now()
+		);
+		CREATE TABLE user_roles (
+			user_id int NOT NULL,
+			role text NOT NULL,
+			PRIMARY KEY (user_id, role)
+		);
+		CREATE TABLE events (name text);
+	`)
+
+	assert.NoError(t, err)
+
+	return &s
+}
+
+// typeCheck parses and type checks the generated code against the packages
+// of the module.
+func typeCheck(t *testing.T, buf []byte) *ast.File {
+	t.Helper()
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "gen.go", buf, 0)
+
+	if err != nil {
+		t.Fatalf("ParseFile() = %+v wanted nil", err)
+	}
+
+	cfg := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+
+	if _, err := cfg.Check(f.Name.Name, fset, []*ast.File{f}, nil); err != nil {
+		t.Errorf("Check() = %+v wanted nil", err)
+	}
+
+	return f
+}
+
+func TestGenerate(t *testing.T) {
+	buf, err := Generate(testSchema(t), WithPackage("store"))
+
+	assert.NoError(t, err)
+
+	f := typeCheck(t, buf)
+
+	assert.Equal(t, "store", f.Name.Name)
+
+	var imports []string
+
+	for _, imp := range f.Imports {
+		imports = append(imports, imp.Path.Value)
+	}
+
+	assert.Equal(
+		t,
+		[]string{
+			`"context"`,
+			`"time"`,
+			`"github.com/upfluence/sql"`,
+			`"github.com/upfluence/sql/sqltypes"`,
+			`"github.com/upfluence/sql/x/sqlbuilder"`,
+			`"github.com/upfluence/sql/x/sqlbuilder/reader"`,
+			`"github.com/upfluence/sql/x/sqlbuilder/upserter"`,
+		},
+		imports,
+	)
+
+	for _, want := range []string{
+		"// Code generated by sqlgen. DO NOT EDIT.",
+		`const UserTableName = "users"`,
+		`UserCreatedAt = sqlbuilder.Column("created_at")`,
+		"UserColumns = []sqlbuilder.Marker{UserID, UserEmail, UserName, UserPayload, UserTags, UserCreatedAt}",
+		"ID        int64                `db:\"id\"`",
+		"Name      *string              `db:\"name\"`",
+		"Payload   sqltypes.JSONValue   `db:\"payload\"`",
+		"Tags      sqltypes.StringSlice `db:\"tags\"`",
+		"CreatedAt time.Time            `db:\"created_at\"`",
+		"func (r *UserRepository) Get(ctx context.Context, id int64) (*User, error)",
+		"Fields: []sqlbuilder.Marker{UserEmail, UserName, UserPayload, UserTags, UserCreatedAt},",
+		").QueryRow(ctx, vs).Scan(\n\t\t&v.ID,\n\t)",
+		"func (r *UserRoleRepository) Delete(ctx context.Context, userID int64, role string) error",
+		"WhereClause: sqlbuilder.And(sqlbuilder.Eq(UserRoleUserID), sqlbuilder.Eq(UserRoleRole)),",
+		"func (r *UserRoleRepository) Upsert(ctx context.Context, v *UserRole) error",
+		"func (r *EventRepository) List(",
+	} {
+		assert.Contains(t, string(buf), want)
+	}
+
+	for _, missing := range []string{
+		// The generated primary key prevents from upserting users.
+		"func (r *UserRepository) Upsert(",
+		// All the columns of user_roles belong to its primary key.
+		"func (r *UserRoleRepository) Update(",
+		"func (r *EventRepository) Get(",
+		"func (r *EventRepository) Delete(",
+	} {
+		assert.NotContains(t, string(buf), missing)
+	}
+}
+
+func TestGenerateDatabaseDefaults(t *testing.T) {
+	buf, err := Generate(testSchema(t), WithDatabaseDefaults())
+
+	assert.NoError(t, err)
+	typeCheck(t, buf)
+	assert.Contains(
+		t,
+		string(buf),
+		"Fields: []sqlbuilder.Marker{UserEmail, UserName, UserPayload, UserTags},",
+	)
+	assert.Contains(
+		t,
+		string(buf),
+		").QueryRow(ctx, vs).Scan(\n\t\t&v.ID,\n\t\t&v.CreatedAt,\n\t)",
+	)
+}
+
+func TestGenerateTables(t *testing.T) {
+	buf, err := Generate(testSchema(t), WithTables("events"))
+
+	assert.NoError(t, err)
+	typeCheck(t, buf)
+	assert.Contains(t, string(buf), "package models")
+	assert.Contains(t, string(buf), "type Event struct")
+	assert.NotContains(t, string(buf), "type User struct")
+	assert.NotContains(t, string(buf), `"time"`)
+
+	_, err = Generate(testSchema(t), WithTables("unknown"))
+	assert.True(t, errors.Is(err, ErrUnknownTable))
+
+	s := testSchema(t)
+	s.Tables = append(s.Tables, &Table{Name: "event"})
+
+	_, err = Generate(s)
+	assert.True(t, errors.Is(err, ErrNameCollision))
+}
+
+func TestGenerateCollisions(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		schema string
+	}{
+		{
+			name:   "marker and columns list",
+			schema: "CREATE TABLE users (id int, columns text);",
+		},
+		{
+			name: "struct and repository",
+			schema: `
+				CREATE TABLE users (id int);
+				CREATE TABLE user_repositories (id int);
+			`,
+		},
+		{
+			name:   "fields",
+			schema: "CREATE TABLE users (user_name text, \"user-name\" text);",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var s Schema
+
+			assert.NoError(t, s.Apply(tt.schema))
+
+			_, err := Generate(&s)
+			assert.True(t, errors.Is(err, ErrNameCollision))
+		})
+	}
+}
+
+func TestGenerateReservedParams(t *testing.T) {
+	var s Schema
+
+	assert.NoError(
+		t,
+		s.Apply("CREATE TABLE items (ctx int, r int, v int, err int, PRIMARY KEY (ctx, r, v, err));"),
+	)
+
+	buf, err := Generate(&s)
+
+	assert.NoError(t, err)
+	typeCheck(t, buf)
+	assert.Contains(
+		t,
+		string(buf),
+		"Get(ctx context.Context, vCtx int64, vR int64, vV int64, vErr int64)",
+	)
+}
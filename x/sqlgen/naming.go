@@ -0,0 +1,102 @@
+package sqlgen
+
+import (
+	"go/token"
+	"strings"
+	"unicode"
+)
+
+var initialisms = map[string]struct{}{
+	"api":  {},
+	"html": {},
+	"http": {},
+	"id":   {},
+	"ip":   {},
+	"json": {},
+	"sql":  {},
+	"uri":  {},
+	"url":  {},
+	"uuid": {},
+}
+
+func splitName(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// goName turns a snake cased SQL name into an exported Go identifier.
+func goName(s string) string {
+	var b strings.Builder
+
+	for _, p := range splitName(strings.ToLower(s)) {
+		if _, ok := initialisms[p]; ok {
+			b.WriteString(strings.ToUpper(p))
+			continue
+		}
+
+		rs := []rune(p)
+		rs[0] = unicode.ToUpper(rs[0])
+
+		b.WriteString(string(rs))
+	}
+
+	if b.Len() == 0 || unicode.IsDigit([]rune(b.String())[0]) {
+		return "X" + b.String()
+	}
+
+	return b.String()
+}
+
+// paramName turns a snake cased SQL name into an unexported Go identifier.
+func paramName(s string) string {
+	var (
+		ps = splitName(strings.ToLower(s))
+		n  string
+	)
+
+	if len(ps) > 0 {
+		n = ps[0] + strings.TrimPrefix(goName(s), goName(ps[0]))
+	}
+
+	if n == "" || !token.IsIdentifier(n) || token.IsKeyword(n) {
+		return "v" + goName(s)
+	}
+
+	return n
+}
+
+// singular naively singularizes the english plural of a table name.
+func singular(s string) string {
+	switch {
+	case strings.HasSuffix(s, "ies") && len(s) > 3:
+		return s[:len(s)-3] + "y"
+	case strings.HasSuffix(s, "sses"),
+		strings.HasSuffix(s, "xes"),
+		strings.HasSuffix(s, "ches"),
+		strings.HasSuffix(s, "shes"):
+		return s[:len(s)-2]
+	case strings.HasSuffix(s, "ss"), strings.HasSuffix(s, "us"):
+		return s
+	case strings.HasSuffix(s, "s") && len(s) > 1:
+		return s[:len(s)-1]
+	}
+
+	return s
+}
+
+// structName returns the name of the Go struct describing a row of the
+// table, the schema qualifying the table is ignored.
+func structName(table string) string {
+	if i := strings.LastIndexByte(table, '.'); i >= 0 {
+		table = table[i+1:]
+	}
+
+	ps := splitName(table)
+
+	if len(ps) > 0 {
+		ps[len(ps)-1] = singular(ps[len(ps)-1])
+	}
+
+	return goName(strings.Join(ps, "_"))
+}
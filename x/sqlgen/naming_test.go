@@ -0,0 +1,44 @@
+package sqlgen
+
+import "testing"
+
+func TestNaming(t *testing.T) {
+	for _, tt := range []struct {
+		in string
+
+		goName, paramName string
+	}{
+		{in: "id", goName: "ID", paramName: "id"},
+		{in: "user_id", goName: "UserID", paramName: "userID"},
+		{in: "avatar_url", goName: "AvatarURL", paramName: "avatarURL"},
+		{in: "api_key", goName: "APIKey", paramName: "apiKey"},
+		{in: "type", goName: "Type", paramName: "vType"},
+		{in: "2fa", goName: "X2fa", paramName: "vX2fa"},
+		{in: "Created At", goName: "CreatedAt", paramName: "createdAt"},
+	} {
+		if n := goName(tt.in); n != tt.goName {
+			t.Errorf("goName(%q) = %q [ want: %q ]", tt.in, n, tt.goName)
+		}
+
+		if n := paramName(tt.in); n != tt.paramName {
+			t.Errorf("paramName(%q) = %q [ want: %q ]", tt.in, n, tt.paramName)
+		}
+	}
+}
+
+func TestStructName(t *testing.T) {
+	for in, want := range map[string]string{
+		"users":             "User",
+		"public.categories": "Category",
+		"addresses":         "Address",
+		"boxes":             "Box",
+		"status":            "Status",
+		"user_settings":     "UserSetting",
+		"access":            "Access",
+		"data":              "Data",
+	} {
+		if n := structName(in); n != want {
+			t.Errorf("structName(%q) = %q [ want: %q ]", in, n, want)
+		}
+	}
+}
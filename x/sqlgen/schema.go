@@ -0,0 +1,419 @@
+package sqlgen
+
+import (
+	"context"
+	"io"
+
+	"github.com/upfluence/errors"
+
+	"github.com/upfluence/sql/sqlparser"
+	"github.com/upfluence/sql/x/migration"
+)
+
+const sqlite3Driver = "sqlite3"
+
+type Column struct {
+	Name string
+	// Type is the lowercased SQL type as declared, such as "varchar(255)".
+	Type string
+
+	NotNull    bool
+	PrimaryKey bool
+	// HasDefault is set for the columns having a default value, they are
+	// still given a value on insert.
+	HasDefault bool
+	// Generated is set for the columns always filled by the database on
+	// insert: serial, identity, auto incremented and generated columns, as
+	// well as the sqlite rowid aliases.
+	Generated bool
+}
+
+type Table struct {
+	Name    string
+	Columns []*Column
+}
+
+func (t *Table) column(name string) (int, *Column) {
+	for i, c := range t.Columns {
+		if c.Name == name {
+			return i, c
+		}
+	}
+
+	return -1, nil
+}
+
+func (t *Table) PrimaryKey() []*Column {
+	var res []*Column
+
+	for _, c := range t.Columns {
+		if c.PrimaryKey {
+			res = append(res, c)
+		}
+	}
+
+	return res
+}
+
+// Schema is the state of the database described by a sequence of DDL
+// statements. Only the statements shaping the tables and their columns are
+// considered, the other ones are ignored.
+type Schema struct {
+	// Driver is the name of the driver the statements are written for, it
+	// tells the dialect specific behaviors apart such as the sqlite rowid.
+	Driver string
+
+	Tables []*Table
+}
+
+func (s *Schema) table(name string) (int, *Table) {
+	for i, t := range s.Tables {
+		if t.Name == name {
+			return i, t
+		}
+	}
+
+	return -1, nil
+}
+
+// LoadSchema applies the up migrations of the source, as picked for the
+// driver, to an empty schema.
+func LoadSchema(ctx context.Context, src migration.Source, d migration.Driver) (*Schema, error) {
+	s := Schema{Driver: d.Name()}
+
+	m, err := src.First(ctx)
+
+	if errors.Is(err, migration.ErrNotExist) {
+		return &s, nil
+	}
+
+	for {
+		if err != nil {
+			return nil, err
+		}
+
+		if err := s.applyMigration(m, d); err != nil {
+			return nil, errors.Wrapf(err, "migration %d", m.ID())
+		}
+
+		ok, next, err := src.Next(ctx, m.ID())
+
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			return &s, nil
+		}
+
+		m, err = src.Get(ctx, next)
+	}
+}
+
+func (s *Schema) applyMigration(m migration.Migration, d migration.Driver) error {
+	r, err := m.Up(d)
+
+	if err != nil {
+		return err
+	}
+
+	defer r.Close()
+
+	buf, err := io.ReadAll(r)
+
+	if err != nil {
+		return err
+	}
+
+	return s.Apply(string(buf))
+}
+
+// Apply updates the schema with the statements separated by semicolons.
+func (s *Schema) Apply(stmts string) error {
+	var ts []sqlparser.Token
+
+	for _, t := range sqlparser.Tokenize(stmts) {
+		switch {
+		case t.Kind == sqlparser.TokenWhitespace || t.Kind == sqlparser.TokenComment:
+		case t.Kind == sqlparser.TokenPunctuation && t.Value == ";":
+			if err := s.applyStatement(ts); err != nil {
+				return err
+			}
+
+			ts = ts[:0]
+		default:
+			ts = append(ts, t)
+		}
+	}
+
+	return s.applyStatement(ts)
+}
+
+func (s *Schema) applyStatement(ts []sqlparser.Token) error {
+	st := stream{ts: ts}
+
+	switch {
+	case st.accept("create"):
+		st.acceptAny("temp", "temporary", "unlogged")
+
+		if !st.accept("table") {
+			return nil
+		}
+
+		return s.createTable(&st)
+	case st.accept("alter", "table"):
+		return s.alterTable(&st)
+	case st.accept("drop", "table"):
+		st.accept("if", "exists")
+
+		for {
+			name, ok := st.qualifiedName()
+
+			if !ok {
+				return st.errorf("table name expected")
+			}
+
+			if i, _ := s.table(name); i >= 0 {
+				s.Tables = append(s.Tables[:i], s.Tables[i+1:]...)
+			}
+
+			if !st.acceptPunct(",") {
+				return nil
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *Schema) createTable(st *stream) error {
+	ifNotExists := st.accept("if", "not", "exists")
+	name, ok := st.qualifiedName()
+
+	if !ok {
+		return st.errorf("table name expected")
+	}
+
+	if _, t := s.table(name); t != nil {
+		if ifNotExists {
+			return nil
+		}
+
+		return st.errorf("table %q already exists", name)
+	}
+
+	t := Table{Name: name}
+
+	if !st.acceptPunct("(") {
+		// CREATE TABLE ... AS SELECT can not be described without the
+		// database.
+		return st.errorf("column definitions expected for table %q", name)
+	}
+
+	for !st.acceptPunct(")") {
+		if st.done() {
+			return st.errorf("unterminated definition of table %q", name)
+		}
+
+		if err := t.applyElement(st); err != nil {
+			return err
+		}
+
+		st.acceptPunct(",")
+	}
+
+	rowid := s.Driver == sqlite3Driver
+
+	for !st.done() {
+		if st.accept("without", "rowid") {
+			rowid = false
+			continue
+		}
+
+		st.skipToken()
+	}
+
+	// A single INTEGER primary key column is an alias of the sqlite rowid.
+	if pk := t.PrimaryKey(); rowid && len(pk) == 1 && pk[0].Type == "integer" {
+		pk[0].Generated = true
+	}
+
+	s.Tables = append(s.Tables, &t)
+
+	return nil
+}
+
+func (t *Table) applyElement(st *stream) error {
+	if st.accept("constraint") {
+		st.identifier()
+	}
+
+	switch {
+	case st.accept("primary", "key"):
+		cols, err := st.columnList()
+
+		if err != nil {
+			return err
+		}
+
+		for _, cn := range cols {
+			if _, c := t.column(cn); c != nil {
+				c.PrimaryKey = true
+				c.NotNull = true
+			}
+		}
+
+		st.skipElement()
+
+		return nil
+	case st.peekWord("unique", "foreign", "check", "exclude", "like", "key", "index"):
+		st.skipElement()
+
+		return nil
+	}
+
+	c, err := st.columnDefinition()
+
+	if err != nil {
+		return err
+	}
+
+	t.Columns = append(t.Columns, c)
+
+	return nil
+}
+
+func (s *Schema) alterTable(st *stream) error {
+	st.accept("if", "exists")
+	st.accept("only")
+
+	name, ok := st.qualifiedName()
+
+	if !ok {
+		return st.errorf("table name expected")
+	}
+
+	ti, t := s.table(name)
+
+	if t == nil {
+		return st.errorf("table %q does not exist", name)
+	}
+
+	for {
+		switch {
+		case st.accept("add"):
+			if st.peekWord("constraint", "primary", "unique", "foreign", "check", "exclude", "index", "key") {
+				if err := t.applyElement(st); err != nil {
+					return err
+				}
+
+				break
+			}
+
+			st.accept("column")
+			ifNotExists := st.accept("if", "not", "exists")
+
+			c, err := st.columnDefinition()
+
+			if err != nil {
+				return err
+			}
+
+			if _, cc := t.column(c.Name); cc != nil {
+				if !ifNotExists {
+					return st.errorf("column %q already exists in %q", c.Name, name)
+				}
+
+				break
+			}
+
+			t.Columns = append(t.Columns, c)
+		case st.accept("drop"):
+			if st.accept("constraint") {
+				st.skipElement()
+				break
+			}
+
+			st.accept("column")
+			st.accept("if", "exists")
+
+			cn, ok := st.identifier()
+
+			if !ok {
+				return st.errorf("column name expected")
+			}
+
+			if i, _ := t.column(cn); i >= 0 {
+				t.Columns = append(t.Columns[:i], t.Columns[i+1:]...)
+			}
+
+			st.skipElement()
+		case st.accept("rename", "to"):
+			nn, ok := st.qualifiedName()
+
+			if !ok {
+				return st.errorf("table name expected")
+			}
+
+			s.Tables[ti].Name = nn
+		case st.accept("rename"):
+			if st.accept("constraint") {
+				st.skipElement()
+				break
+			}
+
+			st.accept("column")
+
+			from, ok := st.identifier()
+
+			if !ok || !st.accept("to") {
+				return st.errorf("invalid column renaming")
+			}
+
+			to, ok := st.identifier()
+
+			if !ok {
+				return st.errorf("column name expected")
+			}
+
+			if _, c := t.column(from); c != nil {
+				c.Name = to
+			}
+		case st.accept("alter"):
+			st.accept("column")
+
+			cn, ok := st.identifier()
+
+			if !ok {
+				return st.errorf("column name expected")
+			}
+
+			_, c := t.column(cn)
+
+			if c == nil {
+				return st.errorf("column %q does not exist in %q", cn, name)
+			}
+
+			switch {
+			case st.accept("set", "not", "null"):
+				c.NotNull = true
+			case st.accept("drop", "not", "null"):
+				c.NotNull = false
+			case st.accept("set", "default"):
+				c.HasDefault = true
+			case st.accept("drop", "default"):
+				c.HasDefault = false
+			case st.accept("set", "data", "type"), st.accept("type"):
+				c.Type = st.columnType()
+			}
+
+			st.skipElement()
+		default:
+			st.skipElement()
+		}
+
+		if !st.acceptPunct(",") {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,194 @@
+package sqlgen
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/upfluence/log"
+	"github.com/upfluence/log/record"
+
+	"github.com/upfluence/sql/x/migration"
+)
+
+type nopSink struct{}
+
+func (nopSink) Log(record.Record) error { return nil }
+
+func TestLoadSchema(t *testing.T) {
+	src := migration.NewStaticSource(
+		[]string{
+			"1_init.up.sql",
+			"1_init.down.sql",
+			"2_roles.up.postgres",
+			"2_roles.up.sqlite3",
+			"3_alter.up.sql",
+		},
+		func(n string) ([]byte, error) {
+			return []byte(map[string]string{
+				"1_init.up.sql": `
+					-- the users
+					CREATE TABLE users (
+						id bigserial PRIMARY KEY,
+						"Email" varchar(255) NOT NULL UNIQUE,
+						name text,
+						score numeric(10, 2),
+						kind text DEFAULT 'basic' NOT NULL,
+						created_at timestamp with time zone NOT NULL DEFAULT now()
+					);
+
+					CREATE TABLE legacy (id int);
+					CREATE INDEX users_name ON users (name);
+				`,
+				"1_init.down.sql": "DROP TABLE users",
+				"2_roles.up.postgres": `
+					CREATE TABLE IF NOT EXISTS public.user_roles (
+						user_id bigint NOT NULL REFERENCES users (id) ON DELETE SET DEFAULT,
+						role text NOT NULL,
+						tags text[],
+						CONSTRAINT user_roles_pk PRIMARY KEY (user_id, role),
+						CHECK (role <> '')
+					)
+				`,
+				"2_roles.up.sqlite3": "CREATE TABLE sqlite_only (id int)",
+				"3_alter.up.sql": `
+					ALTER TABLE users
+						ADD COLUMN avatar_url text,
+						ALTER COLUMN name SET NOT NULL,
+						RENAME COLUMN "Email" TO email;
+					ALTER TABLE users DROP COLUMN score;
+					ALTER TABLE legacy RENAME TO archives;
+					DROP TABLE IF EXISTS archives;
+				`,
+			}[n]), nil
+		},
+		log.NewLogger(log.WithSink(nopSink{})),
+	)
+
+	s, err := LoadSchema(context.Background(), src, migration.PostgresDriver)
+
+	assert.NoError(t, err)
+	assert.Equal(
+		t,
+		&Schema{
+			Driver: "postgres",
+			Tables: []*Table{
+				{
+					Name: "users",
+					Columns: []*Column{
+						{Name: "id", Type: "bigserial", NotNull: true, PrimaryKey: true, Generated: true},
+						{Name: "email", Type: "varchar(255)", NotNull: true},
+						{Name: "name", Type: "text", NotNull: true},
+						{Name: "kind", Type: "text", NotNull: true, HasDefault: true},
+						{Name: "created_at", Type: "timestamp with time zone", NotNull: true, HasDefault: true},
+						{Name: "avatar_url", Type: "text"},
+					},
+				},
+				{
+					Name: "public.user_roles",
+					Columns: []*Column{
+						{Name: "user_id", Type: "bigint", NotNull: true, PrimaryKey: true},
+						{Name: "role", Type: "text", NotNull: true, PrimaryKey: true},
+						{Name: "tags", Type: "text[]"},
+					},
+				},
+			},
+		},
+		s,
+	)
+}
+
+func TestApplyGenerated(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		driver string
+		stmt   string
+		want   []*Column
+	}{
+		{
+			name: "identity",
+			stmt: `CREATE TABLE foo (
+				id bigint GENERATED BY DEFAULT AS IDENTITY,
+				total int GENERATED ALWAYS AS (id * 2) STORED,
+				state text DEFAULT 'new'
+			)`,
+			want: []*Column{
+				{Name: "id", Type: "bigint", Generated: true},
+				{Name: "total", Type: "int", Generated: true},
+				{Name: "state", Type: "text", HasDefault: true},
+			},
+		},
+		{
+			name: "alter default",
+			stmt: `CREATE TABLE foo (id serial, state text DEFAULT 'new', kind text);
+				ALTER TABLE foo
+					ALTER COLUMN id DROP DEFAULT,
+					ALTER COLUMN state DROP DEFAULT,
+					ALTER COLUMN kind SET DEFAULT 'basic'`,
+			want: []*Column{
+				{Name: "id", Type: "serial", NotNull: true, Generated: true},
+				{Name: "state", Type: "text"},
+				{Name: "kind", Type: "text", HasDefault: true},
+			},
+		},
+		{
+			name:   "sqlite rowid",
+			driver: "sqlite3",
+			stmt:   "CREATE TABLE foo (id integer PRIMARY KEY, name text)",
+			want: []*Column{
+				{Name: "id", Type: "integer", NotNull: true, PrimaryKey: true, Generated: true},
+				{Name: "name", Type: "text"},
+			},
+		},
+		{
+			name:   "sqlite without rowid",
+			driver: "sqlite3",
+			stmt:   "CREATE TABLE foo (id integer, PRIMARY KEY (id)) WITHOUT ROWID",
+			want: []*Column{
+				{Name: "id", Type: "integer", NotNull: true, PrimaryKey: true},
+			},
+		},
+		{
+			name: "postgres integer primary key",
+			stmt: "CREATE TABLE foo (id integer PRIMARY KEY)",
+			want: []*Column{
+				{Name: "id", Type: "integer", NotNull: true, PrimaryKey: true},
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			s := Schema{Driver: tt.driver}
+
+			assert.NoError(t, s.Apply(tt.stmt))
+			assert.Equal(t, []*Table{{Name: "foo", Columns: tt.want}}, s.Tables)
+		})
+	}
+}
+
+func TestApplyError(t *testing.T) {
+	for _, tt := range []struct {
+		stmt string
+		err  error
+	}{
+		{
+			stmt: "CREATE TABLE foo (id int); CREATE TABLE foo (id int)",
+			err:  ParseError{Pos: 44, Msg: `table "foo" already exists`},
+		},
+		{
+			stmt: "ALTER TABLE foo ADD COLUMN id int",
+			err:  ParseError{Pos: 16, Msg: `table "foo" does not exist`},
+		},
+		{
+			stmt: "CREATE TABLE foo AS SELECT 1",
+			err:  ParseError{Pos: 17, Msg: `column definitions expected for table "foo"`},
+		},
+		{
+			stmt: "CREATE TABLE foo (id)",
+			err:  ParseError{Pos: 20, Msg: `type expected for column "id"`},
+		},
+	} {
+		var s Schema
+
+		assert.Equal(t, tt.err, s.Apply(tt.stmt), tt.stmt)
+	}
+}
@@ -0,0 +1,354 @@
+package sqlgen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/upfluence/sql/sqlparser"
+)
+
+// ParseError is returned when a DDL statement can not be understood, Pos is
+// the byte offset of the faulty token in the migration.
+type ParseError struct {
+	Pos int
+	Msg string
+}
+
+func (pe ParseError) Error() string {
+	return fmt.Sprintf("sqlgen: %s at position %d", pe.Msg, pe.Pos)
+}
+
+var (
+	serialTypes = map[string]struct{}{
+		"smallserial": {},
+		"serial":      {},
+		"bigserial":   {},
+		"serial2":     {},
+		"serial4":     {},
+		"serial8":     {},
+	}
+
+	referentialActionKeywords = map[string]struct{}{
+		"on":         {},
+		"delete":     {},
+		"update":     {},
+		"cascade":    {},
+		"restrict":   {},
+		"set":        {},
+		"null":       {},
+		"default":    {},
+		"no":         {},
+		"action":     {},
+		"match":      {},
+		"full":       {},
+		"partial":    {},
+		"simple":     {},
+		"deferrable": {},
+		"initially":  {},
+		"deferred":   {},
+		"immediate":  {},
+	}
+
+	constraintKeywords = map[string]struct{}{
+		"not":            {},
+		"null":           {},
+		"primary":        {},
+		"unique":         {},
+		"default":        {},
+		"references":     {},
+		"check":          {},
+		"constraint":     {},
+		"generated":      {},
+		"collate":        {},
+		"comment":        {},
+		"auto_increment": {},
+		"autoincrement":  {},
+	}
+)
+
+// stream walks the significant tokens of a statement.
+type stream struct {
+	ts []sqlparser.Token
+	i  int
+}
+
+func (st *stream) done() bool { return st.i >= len(st.ts) }
+
+func (st *stream) errorf(msg string, args ...interface{}) error {
+	pos := -1
+
+	if !st.done() {
+		pos = st.ts[st.i].Pos
+	} else if len(st.ts) > 0 {
+		pos = st.ts[len(st.ts)-1].Pos
+	}
+
+	return ParseError{Pos: pos, Msg: fmt.Sprintf(msg, args...)}
+}
+
+func (st *stream) word(i int) string {
+	if i >= len(st.ts) || st.ts[i].Kind != sqlparser.TokenWord {
+		return ""
+	}
+
+	return strings.ToLower(st.ts[i].Value)
+}
+
+func (st *stream) isPunct(i int, p string) bool {
+	return i < len(st.ts) &&
+		st.ts[i].Kind == sqlparser.TokenPunctuation &&
+		st.ts[i].Value == p
+}
+
+func (st *stream) peekWord(ws ...string) bool {
+	w := st.word(st.i)
+
+	for _, ww := range ws {
+		if w == ww {
+			return true
+		}
+	}
+
+	return false
+}
+
+// accept consumes the sequence of words if the stream starts with it.
+func (st *stream) accept(ws ...string) bool {
+	for i, w := range ws {
+		if st.word(st.i+i) != w {
+			return false
+		}
+	}
+
+	st.i += len(ws)
+
+	return true
+}
+
+// acceptAny consumes the next token if it is one of the words.
+func (st *stream) acceptAny(ws ...string) bool {
+	if !st.peekWord(ws...) {
+		return false
+	}
+
+	st.i++
+
+	return true
+}
+
+func (st *stream) acceptPunct(p string) bool {
+	if !st.isPunct(st.i, p) {
+		return false
+	}
+
+	st.i++
+
+	return true
+}
+
+func (st *stream) identifier() (string, bool) {
+	if st.done() {
+		return "", false
+	}
+
+	switch t := st.ts[st.i]; t.Kind {
+	case sqlparser.TokenWord:
+		st.i++
+		return strings.ToLower(t.Value), true
+	case sqlparser.TokenQuotedIdentifier:
+		st.i++
+
+		q := t.Value[:1]
+		v := strings.TrimSuffix(strings.TrimPrefix(t.Value, q), q)
+
+		return strings.ReplaceAll(v, q+q, q), true
+	}
+
+	return "", false
+}
+
+func (st *stream) qualifiedName() (string, bool) {
+	var parts []string
+
+	for {
+		name, ok := st.identifier()
+
+		if !ok {
+			return "", false
+		}
+
+		parts = append(parts, name)
+
+		if !st.acceptPunct(".") {
+			return strings.Join(parts, "."), true
+		}
+	}
+}
+
+func (st *stream) columnList() ([]string, error) {
+	var cols []string
+
+	if !st.acceptPunct("(") {
+		return nil, st.errorf("column list expected")
+	}
+
+	for {
+		c, ok := st.identifier()
+
+		if !ok {
+			return nil, st.errorf("column name expected")
+		}
+
+		cols = append(cols, c)
+
+		// Skips the sort order or the operator class of an index element.
+		for !st.done() && !st.isPunct(st.i, ",") && !st.isPunct(st.i, ")") {
+			st.skipToken()
+		}
+
+		if st.acceptPunct(")") {
+			return cols, nil
+		}
+
+		if !st.acceptPunct(",") {
+			return nil, st.errorf("unterminated column list")
+		}
+	}
+}
+
+// skipToken consumes the next token, or the whole parenthesized group it
+// opens.
+func (st *stream) skipToken() {
+	if !st.acceptPunct("(") {
+		st.i++
+		return
+	}
+
+	for depth := 1; depth > 0 && !st.done(); st.i++ {
+		switch {
+		case st.isPunct(st.i, "("):
+			depth++
+		case st.isPunct(st.i, ")"):
+			depth--
+		}
+	}
+}
+
+// skipElement consumes the tokens up to the end of the current element of a
+// table definition or of an ALTER TABLE action.
+func (st *stream) skipElement() {
+	for !st.done() && !st.isPunct(st.i, ",") && !st.isPunct(st.i, ")") {
+		st.skipToken()
+	}
+}
+
+// skipConstraint consumes the tokens up to the next column constraint.
+func (st *stream) skipConstraint() {
+	for !st.done() && !st.atConstraint() && !st.isPunct(st.i, ",") && !st.isPunct(st.i, ")") {
+		st.skipToken()
+	}
+}
+
+func (st *stream) isReferentialAction() bool {
+	if st.peekWord("not") {
+		return st.word(st.i+1) == "deferrable"
+	}
+
+	_, ok := referentialActionKeywords[st.word(st.i)]
+
+	return ok
+}
+
+func (st *stream) atConstraint() bool {
+	_, ok := constraintKeywords[st.word(st.i)]
+
+	return ok
+}
+
+func (st *stream) columnType() string {
+	var b strings.Builder
+
+	for !st.done() && !st.atConstraint() && !st.isPunct(st.i, ",") && !st.isPunct(st.i, ")") {
+		t := st.ts[st.i]
+		v := strings.ToLower(t.Value)
+
+		switch {
+		case t.Kind == sqlparser.TokenPunctuation:
+		case b.Len() == 0:
+		default:
+			if last := b.String()[b.Len()-1]; last != '(' && last != ',' {
+				b.WriteByte(' ')
+			}
+		}
+
+		b.WriteString(v)
+
+		if v == "(" {
+			for st.i++; !st.done() && !st.isPunct(st.i, ")"); st.i++ {
+				b.WriteString(strings.ToLower(st.ts[st.i].Value))
+			}
+
+			b.WriteString(")")
+		}
+
+		st.i++
+	}
+
+	return b.String()
+}
+
+func (st *stream) columnDefinition() (*Column, error) {
+	name, ok := st.identifier()
+
+	if !ok {
+		return nil, st.errorf("column name expected")
+	}
+
+	c := Column{Name: name, Type: st.columnType()}
+
+	if c.Type == "" {
+		return nil, st.errorf("type expected for column %q", name)
+	}
+
+	if _, ok := serialTypes[c.Type]; ok {
+		c.Generated = true
+		c.NotNull = true
+	}
+
+	for !st.done() && !st.isPunct(st.i, ",") && !st.isPunct(st.i, ")") {
+		switch {
+		case st.accept("not", "null"):
+			c.NotNull = true
+		case st.accept("primary", "key"):
+			c.PrimaryKey = true
+			c.NotNull = true
+		case st.accept("references"):
+			st.qualifiedName()
+
+			if st.isPunct(st.i, "(") {
+				st.skipToken()
+			}
+
+			// The referential actions may hold DEFAULT or NULL which must not
+			// be mistaken for the column constraints.
+			for st.isReferentialAction() {
+				st.i++
+			}
+		case st.accept("default"):
+			c.HasDefault = true
+			st.skipConstraint()
+		case st.accept("generated"):
+			c.Generated = true
+
+			// The BY DEFAULT of the identity columns is not a default value.
+			st.accept("by", "default")
+			st.skipConstraint()
+		case st.acceptAny("auto_increment", "autoincrement"):
+			c.Generated = true
+		default:
+			st.skipToken()
+		}
+	}
+
+	return &c, nil
+}